@@ -20,13 +20,43 @@ import (
 var version string
 
 const (
-	rootsOption  = "roots"
-	outputOption = "output"
+	rootsOption          = "roots"
+	outputOption         = "output"
+	dialectOption        = "dialect"
+	baseURIOption        = "base-uri"
+	openAPIOption        = "openapi"
+	formatOption         = "format"
+	bundleOption         = "bundle"
+	derefOption          = "dereference"
+	depthOption          = "max-depth"
+	examplesOption       = "examples"
+	crdOption            = "crd"
+	flattenOption        = "flatten"
+	nullableOption       = "nullable"
+	omitDeprecatedOption = "omit-deprecated"
+	profilesOption       = "profiles"
+	integerBoundsOption  = "integer-bounds"
+	titlesOption         = "titles"
 )
 
 var (
-	roots     []string
-	outputDir string
+	roots          []string
+	outputDir      string
+	dialect        string
+	baseURI        string
+	openAPI        string
+	format         string
+	bundle         bool
+	deref          bool
+	maxDepth       int
+	examples       bool
+	crdSchema      bool
+	flatten        bool
+	nullable       string
+	omitDeprecated bool
+	profiles       bool
+	integerBounds  bool
+	titles         bool
 )
 
 func addGenerator(generators genall.Generators, generator genall.Generator) genall.Generators {
@@ -42,25 +72,97 @@ func RootCmd() *cobra.Command {
 		SilenceUsage:  true,
 		Version:       strings.TrimSpace(version),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var generators genall.Generators
-			generators = addGenerator(generators, &schemas.Generator{OutputDir: outputDir})
-			runtime, err := generators.ForRoots(roots...)
-			if err != nil {
-				return err
-			}
-			if runtime.Run() {
-				return errors.New("generator failed with errors")
-			}
-			return nil
+			return run(&schemas.Generator{
+				OutputDir:      outputDir,
+				Dialect:        dialect,
+				BaseURI:        baseURI,
+				OpenAPI:        openAPI,
+				Format:         format,
+				Bundle:         bundle,
+				Dereference:    deref,
+				MaxDepth:       maxDepth,
+				Examples:       examples,
+				CRD:            crdSchema,
+				Flatten:        flatten,
+				Nullable:       nullable,
+				OmitDeprecated: omitDeprecated,
+				Profiles:       profiles,
+				IntegerBounds:  integerBounds,
+				Titles:         titles,
+			})
 		},
 	}
-	cmd.Flags().StringSliceVarP(&roots, rootsOption, "r", []string{}, "Paths and go-style path patterns to use as package roots")
-	_ = cmd.MarkFlagRequired(rootsOption)
-	cmd.Flags().StringVarP(&outputDir, outputOption, "o", "", "Directory to save JSON schema artifact to")
-	_ = cmd.MarkFlagRequired(outputOption)
+	cmd.PersistentFlags().StringSliceVarP(&roots, rootsOption, "r", []string{}, "Paths and go-style path patterns to use as package roots")
+	_ = cmd.MarkPersistentFlagRequired(rootsOption)
+	cmd.PersistentFlags().StringVarP(&outputDir, outputOption, "o", "", "Directory to save JSON schema artifact to")
+	_ = cmd.MarkPersistentFlagRequired(outputOption)
+	cmd.Flags().StringVar(&dialect, dialectOption, "",
+		"JSON schema draft to emit documents for (draft-07, 2019-09 or 2020-12), adds $schema and $id to each document")
+	cmd.Flags().StringVar(&baseURI, baseURIOption, "", "URI prepended to document names to build their $id")
+	cmd.Flags().StringVar(&openAPI, openAPIOption, "",
+		"OpenAPI version (3.0 or 3.1) of a single document to emit with all definitions under components.schemas")
+	cmd.Flags().StringVar(&format, formatOption, schemas.FormatJSON, "Format of the generated documents (json or yaml)")
+	cmd.Flags().BoolVar(&bundle, bundleOption, false,
+		"Write only the object documents, each with all the definitions it references so that it has no external references")
+	cmd.Flags().BoolVar(&deref, derefOption, false, "Replace every reference with the schema it references")
+	cmd.Flags().IntVar(&maxDepth, depthOption, 0,
+		"Number of times a recursive definition is inlined into itself when dereferencing, recursion is an error if 0")
+	cmd.Flags().BoolVar(&examples, examplesOption, false,
+		"Write a minimal and a maximal example instance of each object, validated against its schema")
+	cmd.Flags().BoolVar(&crdSchema, crdOption, false,
+		"Write the structural openAPIV3Schema of each object for CustomResourceDefinitions, with references inlined")
+	cmd.Flags().BoolVar(&flatten, flattenOption, false,
+		"Merge the properties of embedded structs into the structs embedding them instead of an allOf")
+	cmd.Flags().StringVar(&nullable, nullableOption, schemas.NullableNone,
+		"Nullability of pointer fields: none, nullable for the OpenAPI nullable keyword or type to add null to their type")
+	cmd.Flags().BoolVar(&omitDeprecated, omitDeprecatedOption, false,
+		"Leave the fields with a Deprecated: paragraph in their doc comment out of the schemas")
+	cmd.Flags().BoolVar(&profiles, profilesOption, false,
+		"Write the create, update and read profiles of each object, without its read-only or write-only properties")
+	cmd.Flags().BoolVar(&integerBounds, integerBoundsOption, false,
+		"Set the minimum and maximum of integers to the bounds of their Go kind, unless markers set tighter ones")
+	cmd.Flags().BoolVar(&titles, titlesOption, false,
+		"Set the title of the schemas of types and fields to the first sentence of their doc comment")
+	cmd.AddCommand(TypeScriptCmd(), DocsCmd())
 	return cmd
 }
 
+// TypeScriptCmd defines the cli command generating TypeScript declarations
+func TypeScriptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "typescript",
+		Short: "Generate TypeScript declarations from Go structures",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(&schemas.TypeScriptGenerator{OutputDir: outputDir})
+		},
+	}
+}
+
+// DocsCmd defines the cli command generating Markdown reference documentation
+func DocsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "docs",
+		Short: "Generate Markdown reference documentation from Go structures",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(&schemas.MarkdownGenerator{OutputDir: outputDir})
+		},
+	}
+}
+
+// run runs a generator on the package roots
+func run(generator genall.Generator) error {
+	var generators genall.Generators
+	generators = addGenerator(generators, generator)
+	runtime, err := generators.ForRoots(roots...)
+	if err != nil {
+		return err
+	}
+	if runtime.Run() {
+		return errors.New("generator failed with errors")
+	}
+	return nil
+}
+
 func main() {
 	if err := RootCmd().Execute(); err != nil {
 		fmt.Println(err)
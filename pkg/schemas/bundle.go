@@ -0,0 +1,105 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// definitionRef identifies a definition of a generated document
+type definitionRef struct {
+	document   string
+	definition string
+}
+
+// bundleDocuments returns the object documents, each made self-contained by copying every
+// definition it transitively references from other documents into its own definitions
+func (context *GeneratorContext) bundleDocuments(documents map[string]document) (map[string]document, error) {
+	bundled := make(map[string]document)
+	for docName := range context.objectDocuments {
+		doc, err := context.bundle(docName, documents)
+		if err != nil {
+			return nil, err
+		}
+		bundled[docName] = doc
+	}
+	return bundled, nil
+}
+
+// bundle returns a copy of the named document that only has internal references
+func (context *GeneratorContext) bundle(docName string, documents map[string]document) (document, error) {
+	doc := deepCopyJSON(documents[docName]).(document)
+	definitions := definitionsOf(doc)
+	if definitions == nil {
+		definitions = make(map[string]interface{})
+		doc[definitionsKeyword] = definitions
+	}
+	prefix := "#/" + definitionsKeywordFor(context.generator.Dialect) + "/"
+
+	// local names of the definitions copied into the document
+	localNames := make(map[definitionRef]string)
+	for name := range definitions {
+		localNames[definitionRef{document: docName, definition: name}] = name
+	}
+	var unresolved []string
+	var resolve func(fromDocument string) func(ref string) string
+	resolve = func(fromDocument string) func(ref string) string {
+		return func(ref string) string {
+			refDocument, definitionName, ok := parseDefinitionRef(ref, fromDocument)
+			if !ok {
+				unresolved = append(unresolved, ref)
+				return ref
+			}
+			target := definitionRef{document: refDocument, definition: definitionName}
+			if localName, copied := localNames[target]; copied {
+				return prefix + localName
+			}
+			schema, found := definitionsOf(documents[refDocument])[definitionName].(map[string]interface{})
+			if !found {
+				unresolved = append(unresolved, ref)
+				return ref
+			}
+			localName := bundledName(definitions, target)
+			localNames[target] = localName
+			copied := deepCopyJSON(schema).(map[string]interface{})
+			definitions[localName] = copied
+			// references of the copied definition are relative to the document it comes from
+			rewriteRefs(copied, resolve(refDocument))
+			return prefix + localName
+		}
+	}
+
+	// copied definitions are rewritten as they are added, so only walk the
+	// root and the definitions the document had before
+	originalNames := sortedKeys(definitions)
+	delete(doc, definitionsKeyword)
+	rewriteRefs(doc, resolve(docName))
+	for _, name := range originalNames {
+		rewriteRefs(definitions[name].(map[string]interface{}), resolve(docName))
+	}
+	doc[definitionsKeyword] = definitions
+
+	if len(unresolved) > 0 {
+		sort.Strings(unresolved)
+		return nil, fmt.Errorf("unresolved references in %s: %s", docName, strings.Join(unresolved, ", "))
+	}
+	return doc, nil
+}
+
+// bundledName picks the name of a definition copied into a bundled document,
+// prefixing it with its document name if the plain name is already taken
+func bundledName(definitions map[string]interface{}, ref definitionRef) string {
+	if _, taken := definitions[ref.definition]; !taken {
+		return ref.definition
+	}
+	name := objectName(ref.document) + "." + ref.definition
+	for i := 2; ; i++ {
+		if _, taken := definitions[name]; !taken {
+			return name
+		}
+		name = fmt.Sprintf("%s.%s.%d", objectName(ref.document), ref.definition, i)
+	}
+}
@@ -0,0 +1,66 @@
+package schemas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestBundle(t *testing.T) {
+	documents := generate(t, Generator{Bundle: true}, "../../testPkgs/fybrikobject")
+	if len(documents) != 1 {
+		t.Fatalf("expected only the object document, got %d documents", len(documents))
+	}
+	doc := documents["sample_crd.json"]
+	rewriteRefs(doc, func(ref string) string {
+		if !strings.HasPrefix(ref, "#/definitions/") {
+			t.Errorf("reference %s is not internal", ref)
+		}
+		return ref
+	})
+	if _, ok := definitionsOf(doc)["SchemaType1"]; !ok {
+		t.Error("referenced definition SchemaType1 was not copied into the document")
+	}
+
+	resource, err := createInvalidResource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(doc), gojsonschema.NewBytesLoader(resource))
+	if err != nil {
+		t.Fatalf("could not validate resource against the bundled schema: %v", err)
+	}
+	if result.Valid() {
+		t.Error("expected the invalid resource to be rejected")
+	}
+}
+
+func TestBundleNameClash(t *testing.T) {
+	context := &GeneratorContext{objectDocuments: map[string]bool{"obj.json": true}}
+	bundled, err := context.bundleDocuments(map[string]document{
+		"obj.json": {
+			"$ref":        "#/definitions/A",
+			"definitions": map[string]interface{}{"A": map[string]interface{}{"$ref": "pkg.json#/definitions/A"}},
+		},
+		"pkg.json": {
+			"definitions": map[string]interface{}{
+				"A": map[string]interface{}{"$ref": "#/definitions/B"},
+				"B": map[string]interface{}{"type": "string"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	definitions := definitionsOf(bundled["obj.json"])
+	if ref := definitions["A"].(map[string]interface{})["$ref"]; ref != "#/definitions/pkg.A" {
+		t.Errorf("unexpected reference %v", ref)
+	}
+	if ref := definitions["pkg.A"].(map[string]interface{})["$ref"]; ref != "#/definitions/B" {
+		t.Errorf("unexpected reference %v", ref)
+	}
+	if _, ok := definitions["B"]; !ok {
+		t.Error("transitively referenced definition B is missing")
+	}
+}
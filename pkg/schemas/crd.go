@@ -0,0 +1,196 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	crdSuffix                    = ".crd"
+	openAPIV3SchemaKeyword       = "openAPIV3Schema"
+	preserveUnknownFieldsKeyword = "x-kubernetes-preserve-unknown-fields"
+	intOrStringKeyword           = "x-kubernetes-int-or-string"
+)
+
+// structuralKeywords are the keywords that a structural schema specifies outside of
+// allOf, which are lifted from the allOf left by inlining referenced definitions
+var structuralKeywords = []string{"type", "properties", "items", "additionalProperties", "nullable",
+	preserveUnknownFieldsKeyword, intOrStringKeyword, "x-kubernetes-embedded-resource"}
+
+// unsupportedCRDKeywords are the JSON schema keywords that CustomResourceDefinitions
+// can not express, which are dropped from their schemas
+var unsupportedCRDKeywords = []string{propertyNamesKeyword, deprecatedKeyword, readOnlyKeyword, writeOnlyKeyword,
+	ifKeyword, thenKeyword, elseKeyword, containsKeyword, minContainsKeyword, maxContainsKeyword,
+	dependentRequiredKeyword, dependentSchemasKeyword}
+
+// allowUnknownFields exchanges x-kubernetes-preserve-unknown-fields with
+// additionalProperties: true, which JSON schema validators understand
+func allowUnknownFields(documents map[string]document) {
+	for _, doc := range documents {
+		walkSchemas(doc, func(schema map[string]interface{}) {
+			if schema[preserveUnknownFieldsKeyword] != true {
+				return
+			}
+			delete(schema, preserveUnknownFieldsKeyword)
+			if _, ok := schema["additionalProperties"]; !ok {
+				schema["additionalProperties"] = true
+			}
+		})
+	}
+}
+
+// crdSchemas returns the structural openAPIV3Schema of each object document, with every
+// reference inlined, by the name of the file it is written to. The documents must still
+// have their x-kubernetes-preserve-unknown-fields extensions.
+func (context *GeneratorContext) crdSchemas(documents map[string]document) (map[string]interface{}, error) {
+	d := &dereferencer{
+		documents: documents,
+		maxDepth:  context.generator.MaxDepth,
+		expanding: make(map[definitionRef]int),
+	}
+	schemas := make(map[string]interface{})
+	for docName := range context.objectDocuments {
+		schema := make(map[string]interface{})
+		for keyword, value := range documents[docName] {
+			if keyword != definitionsKeyword && keyword != defsKeyword && keyword != "title" {
+				schema[keyword] = deepCopyJSON(value)
+			}
+		}
+		if err := d.inline(schema, docName); err != nil {
+			return nil, err
+		}
+		toStructural(schema, false)
+		if err := validateStructural(schema); err != nil {
+			return nil, fmt.Errorf("schema of %s is not structural: %w", docName, err)
+		}
+		fileName := fileNameFor(objectName(docName)+crdSuffix+jsonExtension, context.generator.Format)
+		schemas[fileName] = map[string]interface{}{openAPIV3SchemaKeyword: schema}
+	}
+	return schemas, nil
+}
+
+// toStructural rewrites an inlined schema in the form of structural schemas: extensions
+// other than x-kubernetes-* ones, like the names of inlined definitions, are dropped, const
+// is replaced with enum, the allOf of inlined definitions is merged into the
+// schema where possible, and schemas that accept any value preserve unknown fields.
+// Nested schemas, those of allOf, anyOf, oneOf and not, only hold value validations.
+func toStructural(schema map[string]interface{}, nested bool) {
+	dropExtensions(schema)
+	for _, keyword := range unsupportedCRDKeywords {
+		delete(schema, keyword)
+	}
+	constToEnum(schema)
+	if !nested {
+		liftAllOf(schema)
+		if schema["additionalProperties"] == true {
+			delete(schema, "additionalProperties")
+			schema[preserveUnknownFieldsKeyword] = true
+		}
+		if _, typed := schema["type"]; !typed && schema[intOrStringKeyword] == nil {
+			schema[preserveUnknownFieldsKeyword] = true
+		}
+	}
+
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, property := range properties {
+			if propertySchema, ok := property.(map[string]interface{}); ok {
+				toStructural(propertySchema, nested)
+			}
+		}
+	}
+	for _, keyword := range []string{"items", "additionalProperties"} {
+		if subschema, ok := schema[keyword].(map[string]interface{}); ok {
+			toStructural(subschema, nested)
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := schema[keyword].([]interface{})
+		for _, item := range list {
+			if subschema, ok := item.(map[string]interface{}); ok {
+				toStructural(subschema, true)
+			}
+		}
+	}
+	if subschema, ok := schema["not"].(map[string]interface{}); ok {
+		toStructural(subschema, true)
+	}
+}
+
+// liftAllOf moves the structural keywords of the allOf schemas of inlined definitions
+// into the schema, leaving only their value validations in allOf
+func liftAllOf(schema map[string]interface{}) {
+	var remaining []interface{}
+	for _, item := range allOf(schema) {
+		itemSchema, ok := item.(map[string]interface{})
+		if !ok {
+			remaining = append(remaining, item)
+			continue
+		}
+		for _, keyword := range structuralKeywords {
+			value, ok := itemSchema[keyword]
+			if !ok {
+				continue
+			}
+			if existing, exists := schema[keyword]; !exists {
+				schema[keyword] = value
+				delete(itemSchema, keyword)
+			} else if reflect.DeepEqual(existing, value) {
+				delete(itemSchema, keyword)
+			}
+		}
+		dropExtensions(itemSchema)
+		if len(itemSchema) > 0 {
+			remaining = append(remaining, itemSchema)
+		}
+	}
+	if len(remaining) > 0 {
+		schema["allOf"] = remaining
+	} else {
+		delete(schema, "allOf")
+	}
+}
+
+// dropExtensions deletes the extensions of a schema that are not x-kubernetes-* extensions
+func dropExtensions(schema map[string]interface{}) {
+	for keyword := range schema {
+		if strings.HasPrefix(keyword, "x-") && !strings.HasPrefix(keyword, "x-kubernetes-") {
+			delete(schema, keyword)
+		}
+	}
+}
+
+// validateStructural validates a schema against the rules of structural schemas
+// that the API server enforces for CustomResourceDefinitions
+func validateStructural(schema map[string]interface{}) error {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// keywords that are not part of the CRD schema dialect are an error
+	decoder.DisallowUnknownFields()
+	var props apiext.JSONSchemaProps
+	if err := decoder.Decode(&props); err != nil {
+		return err
+	}
+	var internal apiextensions.JSONSchemaProps
+	if err := apiext.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(&props, &internal, nil); err != nil {
+		return err
+	}
+	structural, err := structuralschema.NewStructural(&internal)
+	if err != nil {
+		return err
+	}
+	return structuralschema.ValidateStructural(field.NewPath(openAPIV3SchemaKeyword), structural).ToAggregate()
+}
@@ -0,0 +1,69 @@
+package schemas
+
+import (
+	"testing"
+)
+
+func TestCRDSchemas(t *testing.T) {
+	documents := generate(t, Generator{CRD: true}, "../../testPkgs/fybrikobject")
+	doc, ok := documents["sample_crd.crd.json"]
+	if !ok {
+		t.Fatal("CRD schema is missing")
+	}
+	schema := doc[openAPIV3SchemaKeyword].(map[string]interface{})
+	if schema["type"] != "object" || schema["title"] != nil {
+		t.Errorf("unexpected root %v", schema)
+	}
+	field1 := schema["properties"].(map[string]interface{})["field1"].(map[string]interface{})
+	type1f1 := field1["properties"].(map[string]interface{})["type1f1"].(map[string]interface{})
+	if type1f1["$ref"] != nil || type1f1[definitionNameAnnotation] != nil || type1f1["properties"] == nil {
+		t.Errorf("taxonomy reference is not inlined: %v", type1f1)
+	}
+}
+
+func TestToStructural(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"any":  map[string]interface{}{"description": "any value"},
+			"open": map[string]interface{}{"type": "object", "additionalProperties": true},
+			"ref": map[string]interface{}{
+				"minLength": 2,
+				"allOf": []interface{}{
+					map[string]interface{}{"type": "string", "minLength": 1, definitionNameAnnotation: "Name"},
+				},
+			},
+		},
+	}
+	toStructural(schema, false)
+	if err := validateStructural(schema); err != nil {
+		t.Fatal(err)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	if properties["any"].(map[string]interface{})[preserveUnknownFieldsKeyword] != true {
+		t.Errorf("schema accepting any value should preserve unknown fields: %v", properties["any"])
+	}
+	open := properties["open"].(map[string]interface{})
+	if open[preserveUnknownFieldsKeyword] != true || open["additionalProperties"] != nil {
+		t.Errorf("additionalProperties should be exchanged: %v", open)
+	}
+	ref := properties["ref"].(map[string]interface{})
+	if ref["type"] != "string" || len(allOf(ref)) != 1 || allOf(ref)[0].(map[string]interface{})["type"] != nil {
+		t.Errorf("type should be lifted out of allOf: %v", ref)
+	}
+}
+
+func TestValidateStructural(t *testing.T) {
+	missingType := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"name": map[string]interface{}{"minLength": 1}},
+	}
+	if err := validateStructural(missingType); err == nil {
+		t.Error("expected an error for a property without type")
+	}
+	unknownKeyword := map[string]interface{}{"type": "object", "$defs": map[string]interface{}{}}
+	if err := validateStructural(unknownKeyword); err == nil {
+		t.Error("expected an error for a keyword CRD schemas do not support")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"go/ast"
+	"go/doc/comment"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	deprecatedKeyword         = "deprecated"
+	deprecatedMessageKeyword  = "x-deprecated-message"
+	deprecatedParagraphPrefix = "Deprecated:"
+)
+
+// splitDeprecation removes the "Deprecated:" paragraph of a doc comment from it, and
+// returns that paragraph, or nil if there is none
+func splitDeprecation(doc *comment.Doc) *comment.Paragraph {
+	for i, block := range doc.Content {
+		paragraph, isParagraph := block.(*comment.Paragraph)
+		if isParagraph && strings.HasPrefix(plainText(paragraph.Text), deprecatedParagraphPrefix) {
+			doc.Content = append(doc.Content[:i:i], doc.Content[i+1:]...)
+			return paragraph
+		}
+	}
+	return nil
+}
+
+// describe sets the description of a schema to a doc comment, in CommonMark, and its title to
+// the first sentence if titles are enabled. The schema is marked as deprecated if the doc comment
+// has a "Deprecated:" paragraph, which is left out of the description.
+func describe(ctx *schemaContext, props *apiext.JSONSchemaProps, group *ast.CommentGroup) {
+	doc := ctx.parseDoc(docText(group))
+	deprecation := splitDeprecation(doc)
+	props.Description = ctx.markdown(doc)
+	if ctx.titles {
+		props.Title = firstSentence(doc)
+	}
+	if deprecation == nil {
+		return
+	}
+	ctx.setKeyword(deprecatedKeyword, true)
+	message := ctx.markdown(&comment.Doc{Content: []comment.Block{deprecation}})
+	message = strings.TrimSpace(strings.TrimPrefix(message, deprecatedParagraphPrefix))
+	if message == Empty {
+		return
+	}
+	ctx.setKeyword(deprecatedMessageKeyword, message)
+}
+
+// isDeprecated tells if a doc comment has a "Deprecated:" paragraph
+func isDeprecated(group *ast.CommentGroup) bool {
+	var parser comment.Parser
+	return splitDeprecation(parser.Parse(docText(group))) != nil
+}
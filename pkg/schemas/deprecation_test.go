@@ -0,0 +1,55 @@
+package schemas
+
+import (
+	"go/doc/comment"
+	"strings"
+	"testing"
+)
+
+func TestDeprecated(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/embedded")
+	definitions := documents["embedded.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Scaling"].(map[string]interface{})["properties"].(map[string]interface{})
+	replicas := properties["replicas"].(map[string]interface{})
+	if replicas[deprecatedKeyword] != true || replicas[deprecatedMessageKeyword] != "use Scale instead." ||
+		replicas["description"] != "Replicas of the deployment" {
+		t.Errorf("unexpected deprecated field %v", replicas)
+	}
+	if scale := properties["scale"].(map[string]interface{}); scale[deprecatedKeyword] != nil {
+		t.Errorf("unexpected deprecation of %v", scale)
+	}
+	instances := definitions["Instances"].(map[string]interface{})
+	if instances[deprecatedKeyword] != true || instances["description"] != "Instances is a number of instances" {
+		t.Errorf("unexpected deprecated type %v", instances)
+	}
+}
+
+func TestOmitDeprecated(t *testing.T) {
+	documents := generate(t, Generator{OmitDeprecated: true}, "../../testPkgs/embedded")
+	definitions := documents["embedded.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Scaling"].(map[string]interface{})["properties"].(map[string]interface{})
+	if _, exists := properties["replicas"]; exists {
+		t.Errorf("unexpected deprecated field %v", properties["replicas"])
+	}
+}
+
+func TestSplitDeprecation(t *testing.T) {
+	for _, test := range []struct {
+		doc, description, deprecation string
+	}{
+		{"A field\n", "A field", ""},
+		{"A field\n\nDeprecated: use B.\n\nMore\n", "A field\n\nMore", "Deprecated: use B."},
+		{"Deprecated:\n", "", "Deprecated:"},
+	} {
+		var parser comment.Parser
+		var printer comment.Printer
+		doc := parser.Parse(test.doc)
+		deprecation := Empty
+		if paragraph := splitDeprecation(doc); paragraph != nil {
+			deprecation = strings.TrimSpace(string(printer.Markdown(&comment.Doc{Content: []comment.Block{paragraph}})))
+		}
+		if description := strings.TrimSpace(string(printer.Markdown(doc))); description != test.description || deprecation != test.deprecation {
+			t.Errorf("%q: unexpected %q, %q", test.doc, description, deprecation)
+		}
+	}
+}
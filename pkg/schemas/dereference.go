@@ -0,0 +1,148 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// definitionNameAnnotation keeps the name of the definition an inlined schema was referencing
+const definitionNameAnnotation = "x-definition-name"
+
+// dereferencer inlines the definitions referenced by the generated documents
+type dereferencer struct {
+	documents map[string]document
+	// maxDepth is the number of times a recursive definition is inlined into itself
+	// before it is cut off. Recursive definitions are an error when it is 0.
+	maxDepth int
+	// expanding counts the expansions of each definition that are in progress
+	expanding map[definitionRef]int
+	// path is the chain of definitions that are in progress, for error messages
+	path []definitionRef
+}
+
+// dereferenceDocuments replaces every reference of the documents with the schema it
+// references. Object documents no longer need their definitions, so these are dropped.
+func (context *GeneratorContext) dereferenceDocuments(documents map[string]document) (map[string]document, error) {
+	d := &dereferencer{
+		documents: documents,
+		maxDepth:  context.generator.MaxDepth,
+		expanding: make(map[definitionRef]int),
+	}
+	dereferenced := make(map[string]document, len(documents))
+	for docName, doc := range documents {
+		out := make(document)
+		definitions := make(map[string]interface{})
+		for _, name := range sortedKeys(definitionsOf(doc)) {
+			schema, err := d.expand(definitionRef{document: docName, definition: name})
+			if err != nil {
+				return nil, err
+			}
+			definitions[name] = schema
+		}
+		for keyword, value := range doc {
+			if keyword != definitionsKeyword && keyword != defsKeyword {
+				out[keyword] = deepCopyJSON(value)
+			}
+		}
+		if err := d.inline(out, docName); err != nil {
+			return nil, err
+		}
+		if !context.objectDocuments[docName] {
+			out[definitionsKeyword] = definitions
+		}
+		dereferenced[docName] = out
+	}
+	return dereferenced, nil
+}
+
+// expand returns a copy of a definition with all its references inlined
+func (d *dereferencer) expand(ref definitionRef) (map[string]interface{}, error) {
+	schema, found := definitionsOf(d.documents[ref.document])[ref.definition].(map[string]interface{})
+	if !found {
+		return nil, fmt.Errorf("unresolved reference to definition %s of %s", ref.definition, ref.document)
+	}
+	if d.expanding[ref] > 0 {
+		if d.maxDepth == 0 {
+			return nil, fmt.Errorf("recursive definition can not be dereferenced without a maximum depth: %s",
+				d.describePath(ref))
+		}
+		if d.expanding[ref] >= d.maxDepth {
+			// cut off: any value is accepted from here on
+			return map[string]interface{}{}, nil
+		}
+	}
+	d.expanding[ref]++
+	d.path = append(d.path, ref)
+	defer func() {
+		d.expanding[ref]--
+		d.path = d.path[:len(d.path)-1]
+	}()
+
+	expanded := deepCopyJSON(schema).(map[string]interface{})
+	if err := d.inline(expanded, ref.document); err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+// inline replaces, in place, every reference of the schema with a copy of what it references
+func (d *dereferencer) inline(schema map[string]interface{}, fromDocument string) error {
+	var err error
+	walkSchemas(schema, func(subschema map[string]interface{}) {
+		ref, isRef := subschema["$ref"].(string)
+		if err != nil || !isRef {
+			return
+		}
+		documentName, definitionName, ok := parseDefinitionRef(ref, fromDocument)
+		if !ok {
+			err = fmt.Errorf("unsupported reference %s in %s", ref, fromDocument)
+			return
+		}
+		var expanded map[string]interface{}
+		expanded, err = d.expand(definitionRef{document: documentName, definition: definitionName})
+		if err != nil {
+			return
+		}
+		delete(subschema, "$ref")
+		mergeInlined(subschema, expanded)
+		subschema[definitionNameAnnotation] = definitionName
+	})
+	return err
+}
+
+// mergeInlined merges an inlined schema into the schema that referenced it. Keywords
+// next to the reference, like the description of a field, take precedence over
+// annotations of the inlined schema. If they constrain the value as well, the
+// inlined schema is kept apart in allOf.
+func mergeInlined(schema, inlined map[string]interface{}) {
+	for keyword := range schema {
+		if _, clash := inlined[keyword]; clash && !isAnnotation(keyword) {
+			schema["allOf"] = append([]interface{}{inlined}, allOf(schema)...)
+			return
+		}
+	}
+	for keyword, value := range inlined {
+		if _, exists := schema[keyword]; !exists {
+			schema[keyword] = value
+		}
+	}
+}
+
+// allOf returns the allOf list of a schema, if any
+func allOf(schema map[string]interface{}) []interface{} {
+	list, _ := schema["allOf"].([]interface{})
+	return list
+}
+
+// describePath formats the chain of definitions leading back to ref
+func (d *dereferencer) describePath(ref definitionRef) string {
+	names := make([]string, 0, len(d.path)+1)
+	for _, step := range d.path {
+		names = append(names, step.document+"#"+step.definition)
+	}
+	names = append(names, ref.document+"#"+ref.definition)
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,85 @@
+package schemas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestDereference(t *testing.T) {
+	documents := generate(t, Generator{Dereference: true}, "../../testPkgs/fybrikobject")
+	doc := documents["sample_crd.json"]
+	if _, ok := doc[definitionsKeyword]; ok {
+		t.Error("object documents should not keep definitions")
+	}
+	for name, doc := range documents {
+		walkSchemas(doc, func(schema map[string]interface{}) {
+			if ref, ok := schema["$ref"]; ok {
+				t.Errorf("%s: reference %v was not inlined", name, ref)
+			}
+		})
+	}
+	field1 := doc["properties"].(map[string]interface{})["field1"].(map[string]interface{})
+	if field1[definitionNameAnnotation] != "Type1" {
+		t.Errorf("unexpected definition name %v", field1[definitionNameAnnotation])
+	}
+
+	resource, err := createResource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(doc), gojsonschema.NewBytesLoader(resource))
+	if err != nil {
+		t.Fatalf("could not validate resource against the dereferenced schema: %v", err)
+	}
+	if !result.Valid() {
+		t.Errorf("expected a valid resource: %v", result.Errors())
+	}
+}
+
+func recursiveDocuments() map[string]document {
+	return map[string]document{
+		"pkg.json": {
+			"definitions": map[string]interface{}{
+				"Node": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"children": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]interface{}{"$ref": "#/definitions/Node", "description": "a child"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDereferenceRecursive(t *testing.T) {
+	context := &GeneratorContext{objectDocuments: map[string]bool{}}
+	_, err := context.dereferenceDocuments(recursiveDocuments())
+	if err == nil || !strings.Contains(err.Error(), "pkg.json#Node -> pkg.json#Node") {
+		t.Errorf("expected an error naming the recursive definition, got %v", err)
+	}
+}
+
+func TestDereferenceMaxDepth(t *testing.T) {
+	context := &GeneratorContext{generator: Generator{MaxDepth: 2}, objectDocuments: map[string]bool{}}
+	documents, err := context.dereferenceDocuments(recursiveDocuments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	depth := 0
+	node := definitionsOf(documents["pkg.json"])["Node"].(map[string]interface{})
+	for node["properties"] != nil {
+		node = node["properties"].(map[string]interface{})["children"].(map[string]interface{})["items"].(map[string]interface{})
+		if node["description"] != "a child" || node[definitionNameAnnotation] != "Node" {
+			t.Errorf("unexpected inlined schema %v", node)
+		}
+		depth++
+	}
+	if depth != 2 {
+		t.Errorf("expected the definition to be inlined twice, got %d", depth)
+	}
+}
@@ -0,0 +1,357 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Supported values for Generator.Dialect
+const (
+	DialectDraft07 = "draft-07"
+	Dialect201909  = "2019-09"
+	Dialect202012  = "2020-12"
+)
+
+const (
+	definitionsKeyword = "definitions"
+	defsKeyword        = "$defs"
+)
+
+// metaSchemas maps a dialect to the URI of its meta-schema
+var metaSchemas = map[string]string{
+	DialectDraft07: "http://json-schema.org/draft-07/schema#",
+	Dialect201909:  "https://json-schema.org/draft/2019-09/schema",
+	Dialect202012:  "https://json-schema.org/draft/2020-12/schema",
+}
+
+// document is the generic JSON form of a generated schema document.
+// Generation works on apiext.JSONSchemaProps, but the output steps
+// need keywords it has no fields for, so documents are converted to
+// this form before they are written.
+type document map[string]interface{}
+
+// toDocument converts a generated schema to its generic JSON form
+func toDocument(props *apiext.JSONSchemaProps) (document, error) {
+	raw, err := json.Marshal(props)
+	if err != nil {
+		return nil, err
+	}
+	var doc document
+	if err := unmarshalJSON(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// unmarshalJSON decodes JSON keeping numbers as json.Number, so that
+// integers survive the round trip unchanged
+func unmarshalJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// validateDialect checks that the dialect is one of the supported ones
+func validateDialect(dialect string) error {
+	if dialect == Empty {
+		return nil
+	}
+	if _, ok := metaSchemas[dialect]; !ok {
+		return fmt.Errorf("unsupported dialect %q, expected one of %s, %s or %s",
+			dialect, DialectDraft07, Dialect201909, Dialect202012)
+	}
+	return nil
+}
+
+// definitionsKeywordFor returns the keyword under which a dialect keeps definitions
+func definitionsKeywordFor(dialect string) string {
+	switch dialect {
+	case Dialect201909, Dialect202012:
+		return defsKeyword
+	default:
+		return definitionsKeyword
+	}
+}
+
+// applyDialect rewrites a document for the given dialect and stamps it with
+// the $schema and $id keywords
+func applyDialect(doc document, dialect, id string) {
+	if dialect == Empty {
+		return
+	}
+	if keyword := definitionsKeywordFor(dialect); keyword != definitionsKeyword {
+		if definitions, ok := doc[definitionsKeyword]; ok {
+			doc[keyword] = definitions
+			delete(doc, definitionsKeyword)
+		}
+	}
+	toJSONSchemaKeywords(doc)
+	doc["$schema"] = metaSchemas[dialect]
+	doc["$id"] = id
+}
+
+// toJSONSchemaKeywords replaces the OpenAPI 3.0 forms of keywords, as generated
+// from apiext.JSONSchemaProps, with their JSON schema forms
+func toJSONSchemaKeywords(schema map[string]interface{}) {
+	walkSchemas(schema, func(subschema map[string]interface{}) {
+		exclusiveBoundToNumber(subschema, "minimum", "exclusiveMinimum")
+		exclusiveBoundToNumber(subschema, "maximum", "exclusiveMaximum")
+		nullableToType(subschema)
+	})
+}
+
+// constToEnum replaces the const keyword, that OpenAPI 3.0 and CRD schemas lack,
+// with an enum of its single value
+func constToEnum(schema map[string]interface{}) {
+	if value, ok := schema[constKeyword]; ok {
+		schema["enum"] = []interface{}{value}
+		delete(schema, constKeyword)
+	}
+}
+
+// exclusiveBoundToNumber replaces the draft-04 boolean form of exclusiveMinimum
+// and exclusiveMaximum with the numeric form used since draft-06
+func exclusiveBoundToNumber(schema map[string]interface{}, bound, exclusiveBound string) {
+	exclusive, ok := schema[exclusiveBound].(bool)
+	if !ok {
+		return
+	}
+	delete(schema, exclusiveBound)
+	if value, hasBound := schema[bound]; exclusive && hasBound {
+		schema[exclusiveBound] = value
+		delete(schema, bound)
+	}
+}
+
+// nullableToType replaces the OpenAPI nullable keyword, which JSON schema
+// does not know, with a type that admits null
+func nullableToType(schema map[string]interface{}) {
+	nullable, ok := schema["nullable"].(bool)
+	if !ok {
+		return
+	}
+	delete(schema, "nullable")
+	if !nullable {
+		return
+	}
+	// an enum has to admit null as well
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsNull(enum) {
+		schema["enum"] = append(enum, nil)
+	}
+	switch typ := schema["type"].(type) {
+	case string:
+		schema["type"] = []interface{}{typ, "null"}
+	case nil:
+		// a sibling type would restrict a reference (or any other keyword)
+		// instead of widening it, so null has to be a separate branch
+		branch := make(map[string]interface{})
+		for keyword, value := range schema {
+			if !isAnnotation(keyword) {
+				branch[keyword] = value
+				delete(schema, keyword)
+			}
+		}
+		// a reference wrapped in an allOf to be nullable in OpenAPI 3.0 is unwrapped
+		if refs, ok := branch["allOf"].([]interface{}); ok && len(refs) == 1 && len(branch) == 1 {
+			if ref, ok := refs[0].(map[string]interface{}); ok {
+				branch = ref
+			}
+		}
+		schema["anyOf"] = []interface{}{branch, map[string]interface{}{"type": "null"}}
+	}
+}
+
+// containsNull reports whether a list of values contains null
+func containsNull(values []interface{}) bool {
+	for _, value := range values {
+		if value == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isAnnotation reports whether a keyword only documents a schema
+func isAnnotation(keyword string) bool {
+	switch keyword {
+	case "title", "description", "default", "example", "examples":
+		return true
+	}
+	return false
+}
+
+// schemaMapKeywords are keywords whose value is a map of schemas
+var schemaMapKeywords = []string{"properties", "patternProperties", definitionsKeyword, defsKeyword, "dependentSchemas"}
+
+// schemaListKeywords are keywords whose value is a list of schemas
+var schemaListKeywords = []string{"allOf", "anyOf", "oneOf", "prefixItems"}
+
+// schemaKeywords are keywords whose value is a single schema
+var schemaKeywords = []string{"not", "if", "then", "else", "contains", "propertyNames", "additionalItems", "unevaluatedItems",
+	"unevaluatedProperties"}
+
+// walkSchemas calls fn for the given schema and every schema nested in it,
+// parents before their children
+func walkSchemas(schema map[string]interface{}, fn func(map[string]interface{})) {
+	fn(schema)
+	eachSubschema(schema, func(subschema map[string]interface{}) {
+		walkSchemas(subschema, fn)
+	})
+}
+
+// dropKeywords removes keywords from a schema and every schema nested in it, and returns
+// those it had, in the given order
+func dropKeywords(schema map[string]interface{}, keywords []string) []string {
+	found := make(map[string]bool)
+	walkSchemas(schema, func(subschema map[string]interface{}) {
+		for _, keyword := range keywords {
+			if _, ok := subschema[keyword]; ok {
+				found[keyword] = true
+				delete(subschema, keyword)
+			}
+		}
+	})
+	var dropped []string
+	for _, keyword := range keywords {
+		if found[keyword] {
+			dropped = append(dropped, keyword)
+		}
+	}
+	return dropped
+}
+
+// subschemaVisitors are the keywords whose value holds schemas, in the order they are
+// visited, with the function that visits the schemas of their value
+var subschemaVisitors = []struct {
+	keywords []string
+	each     func(value interface{}, fn func(map[string]interface{}))
+}{
+	{schemaMapKeywords, eachNamedSchema},
+	{schemaListKeywords, eachSchemaOf},
+	{schemaKeywords, eachSchemaOf},
+	// items and additionalProperties can also be a list and a boolean respectively
+	{[]string{"items", "additionalProperties"}, eachSchemaOf},
+	// dependencies mixes schemas with lists of property names
+	{[]string{"dependencies"}, eachNamedSchema},
+}
+
+// eachSubschema calls fn for every schema directly nested in the given schema
+func eachSubschema(schema map[string]interface{}, fn func(map[string]interface{})) {
+	for _, visitor := range subschemaVisitors {
+		for _, keyword := range visitor.keywords {
+			if value, ok := schema[keyword]; ok {
+				visitor.each(value, fn)
+			}
+		}
+	}
+}
+
+// eachNamedSchema calls fn for every schema of a map of schemas, by name
+func eachNamedSchema(value interface{}, fn func(map[string]interface{})) {
+	if schemas, ok := value.(map[string]interface{}); ok {
+		for _, name := range sortedKeys(schemas) {
+			if subschema, ok := schemas[name].(map[string]interface{}); ok {
+				fn(subschema)
+			}
+		}
+	}
+}
+
+// eachSchemaOf calls fn for a schema, or for every schema of a list of schemas
+func eachSchemaOf(value interface{}, fn func(map[string]interface{})) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		fn(typed)
+	case []interface{}:
+		for _, item := range typed {
+			if subschema, ok := item.(map[string]interface{}); ok {
+				fn(subschema)
+			}
+		}
+	}
+}
+
+// sortedKeys returns the keys of a map in a stable order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rewriteRefs replaces every $ref in the schema, and the reference of every doc link of its
+// descriptions, with the result of fn
+func rewriteRefs(schema map[string]interface{}, fn func(ref string) string) {
+	walkSchemas(schema, func(subschema map[string]interface{}) {
+		if ref, ok := subschema["$ref"].(string); ok {
+			subschema["$ref"] = fn(ref)
+		}
+		// the doc links of descriptions are references too
+		replaceDocLinks(subschema, func(text, ref string) string {
+			return "[" + text + "](" + fn(ref) + ")"
+		})
+	})
+}
+
+// parseDefinitionRef splits a reference built by TypeRefLink into the name of the
+// document it points to and the name of the definition in that document.
+// References without a document part point into fromDocument.
+func parseDefinitionRef(ref, fromDocument string) (documentName, definitionName string, ok bool) {
+	documentName, pointer, found := strings.Cut(ref, "#")
+	if !found {
+		return Empty, Empty, false
+	}
+	if documentName == Empty {
+		documentName = fromDocument
+	}
+	for _, keyword := range []string{definitionsKeyword, defsKeyword} {
+		prefix := "/" + keyword + "/"
+		if strings.HasPrefix(pointer, prefix) {
+			return documentName, strings.TrimPrefix(pointer, prefix), true
+		}
+	}
+	return Empty, Empty, false
+}
+
+// definitionsOf returns the definitions of a document, under either keyword
+func definitionsOf(doc map[string]interface{}) map[string]interface{} {
+	if definitions, ok := doc[definitionsKeyword].(map[string]interface{}); ok {
+		return definitions
+	}
+	if definitions, ok := doc[defsKeyword].(map[string]interface{}); ok {
+		return definitions
+	}
+	return nil
+}
+
+// deepCopyJSON copies a generic JSON value
+func deepCopyJSON(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			out[key] = deepCopyJSON(item)
+		}
+		return out
+	case document:
+		return document(deepCopyJSON(map[string]interface{}(typed)).(map[string]interface{}))
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, item := range typed {
+			out[i] = deepCopyJSON(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
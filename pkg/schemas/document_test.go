@@ -0,0 +1,155 @@
+package schemas
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+)
+
+// generate runs the generator on the given roots and returns the written documents by their
+// path relative to the output directory
+func generate(t *testing.T, g Generator, roots ...string) map[string]document {
+	t.Helper()
+	g.OutputDir = t.TempDir()
+	var generator genall.Generator = &g
+	generators := genall.Generators{&generator}
+	runtime, err := generators.ForRoots(roots...)
+	if err != nil {
+		t.Fatalf("could not load roots: %v", err)
+	}
+	if runtime.Run() {
+		t.Fatal("generator failed with errors")
+	}
+
+	documents := make(map[string]document)
+	err = filepath.WalkDir(g.OutputDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(g.OutputDir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("could not read %s: %v", name, err)
+		}
+		// nested objects are decoded to the type of the outer map, so avoid the named type
+		var doc map[string]interface{}
+		if filepath.Ext(name) == yamlExtension {
+			err = yaml.Unmarshal(data, &doc)
+		} else {
+			err = unmarshalJSON(data, &doc)
+		}
+		if err != nil {
+			t.Fatalf("could not parse %s: %v", name, err)
+		}
+		documents[filepath.ToSlash(name)] = doc
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("could not read output: %v", err)
+	}
+	return documents
+}
+
+func TestDialect202012(t *testing.T) {
+	documents := generate(t, Generator{Dialect: Dialect202012, BaseURI: "https://example.com/schemas/"}, "../../testPkgs/fybrikobject")
+	for name, doc := range documents {
+		if doc["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+			t.Errorf("%s: unexpected $schema %v", name, doc["$schema"])
+		}
+		if doc["$id"] != "https://example.com/schemas/"+name {
+			t.Errorf("%s: unexpected $id %v", name, doc["$id"])
+		}
+		if _, ok := doc[definitionsKeyword]; ok {
+			t.Errorf("%s: legacy definitions keyword is still present", name)
+		}
+		if _, ok := doc[defsKeyword]; !ok {
+			t.Errorf("%s: $defs keyword is missing", name)
+		}
+		walkSchemas(doc, func(schema map[string]interface{}) {
+			if ref, ok := schema["$ref"].(string); ok && !strings.Contains(ref, "#/$defs/") {
+				t.Errorf("%s: reference %s does not point into $defs", name, ref)
+			}
+		})
+	}
+	ref := documents["sample_crd.json"]["properties"].(map[string]interface{})["field1"].(map[string]interface{})["$ref"]
+	if ref != "#/$defs/Type1" {
+		t.Errorf("unexpected reference %v", ref)
+	}
+}
+
+func TestDialectDraft07(t *testing.T) {
+	documents := generate(t, Generator{Dialect: DialectDraft07}, "../../testPkgs/fybrikobject")
+	doc := documents["schemapkg.json"]
+	if doc["$schema"] != "http://json-schema.org/draft-07/schema#" || doc["$id"] != "schemapkg.json" {
+		t.Errorf("unexpected $schema or $id: %v %v", doc["$schema"], doc["$id"])
+	}
+	if _, ok := doc[definitionsKeyword]; !ok {
+		t.Error("draft-07 documents should keep the definitions keyword")
+	}
+}
+
+func TestApplyDialect(t *testing.T) {
+	doc := document{
+		"properties": map[string]interface{}{
+			"count": map[string]interface{}{"type": "integer", "minimum": 1, "exclusiveMinimum": true, "exclusiveMaximum": false},
+			"ref":   map[string]interface{}{"$ref": "#/definitions/A", "nullable": true, "description": "a ref"},
+			"name":  map[string]interface{}{"type": "string", "nullable": true},
+		},
+	}
+	applyDialect(doc, Dialect202012, "doc.json")
+
+	properties := doc["properties"].(map[string]interface{})
+	count := properties["count"].(map[string]interface{})
+	if count["exclusiveMinimum"] != 1 || count["minimum"] != nil || count["exclusiveMaximum"] != nil {
+		t.Errorf("unexpected bounds %v", count)
+	}
+	name := properties["name"].(map[string]interface{})
+	if types, ok := name["type"].([]interface{}); !ok || len(types) != 2 || types[1] != "null" {
+		t.Errorf("unexpected nullable type %v", name["type"])
+	}
+	ref := properties["ref"].(map[string]interface{})
+	if ref["$ref"] != nil || ref["description"] != "a ref" || len(ref["anyOf"].([]interface{})) != 2 {
+		t.Errorf("unexpected nullable reference %v", ref)
+	}
+}
+
+func TestValidateDialect(t *testing.T) {
+	if err := validateDialect("draft-04"); err == nil {
+		t.Error("expected an error for an unsupported dialect")
+	}
+}
+
+func TestExternalDocument(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/fybrikobject")
+	doc := documents["external.json"]
+	definitions := definitionsOf(doc)
+	sampleCrd := definitions["fybrik.io~1json-schema-generator~1testPkgs~1fybrikobject~0SampleCrd"].(map[string]interface{})
+	if !reflect.DeepEqual(sampleCrd["required"], []interface{}{"field1", "field2", "field3"}) {
+		t.Errorf("unexpected required properties %v", sampleCrd["required"])
+	}
+	if properties := sampleCrd["properties"].(map[string]interface{}); len(properties) != 3 {
+		t.Errorf("unexpected properties %v", properties)
+	}
+	// the object document is trimmed, the definitions of the external document are not
+	if _, ok := documents["sample_crd.json"]["properties"].(map[string]interface{})["field2"]; ok {
+		t.Error("field2 should be removed from the object document")
+	}
+	walkSchemas(doc, func(schema map[string]interface{}) {
+		ref, ok := schema["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#") {
+			return
+		}
+		if _, definitionName, _ := parseDefinitionRef(ref, "external.json"); definitions[definitionName] == nil {
+			t.Errorf("reference %s does not resolve", ref)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// enumDescriptionsKeyword lists the descriptions of the values of enum, in the same order
+const enumDescriptionsKeyword = "x-enum-descriptions"
+
+// enumValue is a typed constant, as a value of the enum of its type
+type enumValue struct {
+	value       interface{}
+	description string
+}
+
+// inferEnum sets the enum of the schema of a named string or integer type to the values
+// of the constants declared with that type in its package. The doc comments of the
+// constants are kept as the descriptions of the values.
+func inferEnum(ctx *schemaContext, props *apiext.JSONSchemaProps) {
+	if len(props.Enum) > 0 || (props.Type != "string" && props.Type != "integer") {
+		return
+	}
+	obj := ctx.pkg.Types.Scope().Lookup(ctx.info.Name)
+	if obj == nil {
+		return
+	}
+	values := typedConstants(ctx.pkg, obj.Type())
+	if len(values) == 0 {
+		return
+	}
+
+	descriptions := make([]string, 0, len(values))
+	described := false
+	for _, value := range values {
+		raw, err := json.Marshal(value.value)
+		if err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, ctx.info.RawSpec))
+			return
+		}
+		props.Enum = append(props.Enum, apiext.JSON{Raw: raw})
+		descriptions = append(descriptions, value.description)
+		described = described || value.description != Empty
+	}
+	if described {
+		ctx.setKeyword(enumDescriptionsKeyword, descriptions)
+	}
+}
+
+// typedConstants returns the values of the constants of a package declared with the given type,
+// in the order they are first declared in. Constants with the same value, such as aliases, are
+// a single value with their descriptions merged.
+func typedConstants(pkg *loader.Package, typ types.Type) []enumValue {
+	var values []enumValue
+	indices := make(map[interface{}]int)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, isGenDecl := decl.(*ast.GenDecl)
+			if !isGenDecl || genDecl.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				for _, name := range valueSpec.Names {
+					obj, isConst := pkg.TypesInfo.Defs[name].(*types.Const)
+					if !isConst || name.Name == "_" || !types.Identical(obj.Type(), typ) {
+						continue
+					}
+					value, ok := constantValue(obj.Val())
+					if !ok {
+						continue
+					}
+					description := constantDoc(valueSpec)
+					index, seen := indices[value]
+					if !seen {
+						indices[value] = len(values)
+						values = append(values, enumValue{value: value, description: description})
+						continue
+					}
+					if merged := &values[index].description; description != Empty && *merged != description {
+						if *merged != Empty {
+							description = *merged + "; " + description
+						}
+						*merged = description
+					}
+				}
+			}
+		}
+	}
+	return values
+}
+
+// constantValue converts a string or integer constant to its JSON value
+func constantValue(value constant.Value) (interface{}, bool) {
+	switch value.Kind() {
+	case constant.String:
+		return constant.StringVal(value), true
+	case constant.Int:
+		return constant.Int64Val(value)
+	default:
+		return nil, false
+	}
+}
+
+// constantDoc returns the doc comment of a constant, or its line comment, on a single line
+func constantDoc(spec *ast.ValueSpec) string {
+	comment := spec.Doc
+	if comment == nil {
+		comment = spec.Comment
+	}
+	if comment == nil {
+		return Empty
+	}
+	return strings.Join(strings.Fields(comment.Text()), " ")
+}
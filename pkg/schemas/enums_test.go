@@ -0,0 +1,37 @@
+package schemas
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEnumInference(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/plugins")
+	definitions := documents["plugins.json"][definitionsKeyword].(map[string]interface{})
+
+	algorithm := definitions["Algorithm"].(map[string]interface{})
+	if !reflect.DeepEqual(algorithm["enum"], []interface{}{"sha256", "md5"}) {
+		t.Errorf("unexpected enum %v", algorithm["enum"])
+	}
+	if !reflect.DeepEqual(algorithm[enumDescriptionsKeyword],
+		[]interface{}{"SHA256 is the SHA-2 algorithm with 256 bits digests; SHA2 is an alias of SHA256", "deprecated"}) {
+		t.Errorf("unexpected descriptions %v", algorithm[enumDescriptionsKeyword])
+	}
+	if _, ok := algorithm["id"]; ok {
+		t.Error("unexpected id keyword")
+	}
+
+	level := definitions["Level"].(map[string]interface{})
+	if !reflect.DeepEqual(level["enum"], []interface{}{json.Number("1"), json.Number("2")}) {
+		t.Errorf("unexpected enum %v", level["enum"])
+	}
+	if _, ok := level[enumDescriptionsKeyword]; ok {
+		t.Error("undocumented values should not have descriptions")
+	}
+
+	mode := definitions["Mode"].(map[string]interface{})
+	if !reflect.DeepEqual(mode["enum"], []interface{}{"strict", "lenient", "audit"}) {
+		t.Errorf("enum marker should take precedence: %v", mode["enum"])
+	}
+}
@@ -0,0 +1,781 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	minimalExampleSuffix = ".example.min"
+	maximalExampleSuffix = ".example.max"
+	exampleString        = "example"
+)
+
+// formatExamples are the example values of strings with a format
+var formatExamples = map[string]string{
+	"date":      "2021-01-01",
+	"date-time": "2021-01-01T00:00:00Z",
+	"time":      "00:00:00",
+	"duration":  "1h",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "https://example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"byte":      "ZXhhbXBsZQ==",
+}
+
+// preferredRunes are picked, in order, to match a character class of a pattern
+const preferredRunes = "a0A-_."
+
+// exampleGenerator builds example instances of the schemas of a bundled document
+type exampleGenerator struct {
+	docName string
+	doc     document
+	// maximal sets every optional property and fills arrays and maps, instead of
+	// only setting what the schema requires
+	maximal bool
+	// expanding counts the expansions of each definition that are in progress
+	expanding map[string]int
+}
+
+// exampleDocuments returns a minimal and a maximal example instance of each object
+// document, by the name of the file they are written to. Each example is validated
+// against the bundled object document.
+func (context *GeneratorContext) exampleDocuments(documents map[string]document) (map[string]interface{}, error) {
+	examples := make(map[string]interface{})
+	for docName := range context.objectDocuments {
+		doc, err := context.bundle(docName, documents)
+		if err != nil {
+			return nil, err
+		}
+		for _, maximal := range []bool{false, true} {
+			suffix := minimalExampleSuffix
+			if maximal {
+				suffix = maximalExampleSuffix
+			}
+			// an example that can not be generated is left out, the other documents still get theirs
+			e := &exampleGenerator{docName: docName, doc: doc, maximal: maximal, expanding: make(map[string]int)}
+			example, err := e.value(doc)
+			if err != nil {
+				log.Printf("%s: warning: could not generate the %s example: %v", docName, strings.TrimPrefix(suffix, "."), err)
+				continue
+			}
+			if err := validateExample(doc, example); err != nil {
+				log.Printf("%s: warning: generated %s example is not valid: %v", docName, strings.TrimPrefix(suffix, "."), err)
+				continue
+			}
+			examples[fileNameFor(objectName(docName)+suffix+jsonExtension, context.generator.Format)] = example
+		}
+	}
+	return examples, nil
+}
+
+// validateExample validates an example instance against the bundled document it was generated from
+func validateExample(doc document, example interface{}) error {
+	schema := deepCopyJSON(doc).(document)
+	if definitions, ok := schema[definitionsKeyword]; ok {
+		// the bundled references point into the keyword of the dialect
+		schema[defsKeyword] = definitions
+	}
+	// gojsonschema only knows the draft-07 form of dependentRequired and dependentSchemas
+	walkSchemas(schema, toDependencies)
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(schema), gojsonschema.NewGoLoader(example))
+	if err != nil {
+		return err
+	}
+	if !result.Valid() {
+		var problems []string
+		for _, problem := range result.Errors() {
+			problems = append(problems, problem.String())
+		}
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// value returns an example instance of a schema
+func (e *exampleGenerator) value(schema map[string]interface{}) (interface{}, error) {
+	if value, found, err := e.keywordValue(schema); found {
+		return value, err
+	}
+	if value, found, err := e.typedValue(schema); found {
+		return value, err
+	}
+	if branch := firstBranch(schema); branch != nil {
+		return e.value(branch)
+	}
+	// any value is accepted
+	return map[string]interface{}{}, nil
+}
+
+// keywordValue returns the example instance decided by a keyword of a schema other than
+// type: a reference, a default, const or enum value, or one given by not, if or allOf
+func (e *exampleGenerator) keywordValue(schema map[string]interface{}) (interface{}, bool, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		value, err := e.reference(ref)
+		return value, true, err
+	}
+	if value, ok := schema["default"]; ok {
+		return deepCopyJSON(value), true, nil
+	}
+	if value, ok := schema[constKeyword]; ok {
+		return deepCopyJSON(value), true, nil
+	}
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		return deepCopyJSON(values[0]), true, nil
+	}
+	if not, ok := schema[notKeyword].(map[string]interface{}); ok {
+		value, err := e.negated(schema, not)
+		return value, true, err
+	}
+	if _, ok := schema[ifKeyword]; ok {
+		value, err := e.conditional(schema)
+		return value, true, err
+	}
+	if items := allOf(schema); len(items) > 0 {
+		value, err := e.allOf(schema, items)
+		return value, true, err
+	}
+	return nil, false, nil
+}
+
+// typedValue returns an example instance of the type of a schema, if known
+func (e *exampleGenerator) typedValue(schema map[string]interface{}) (interface{}, bool, error) {
+	var value interface{}
+	var err error
+	switch typeOfSchema(schema) {
+	case "object":
+		value, err = e.object(schema)
+	case "array":
+		value, err = e.array(schema)
+	case "string":
+		value, err = stringExample(schema)
+	case "integer":
+		value = e.number(schema, true)
+	case "number":
+		value = e.number(schema, false)
+	case "boolean":
+		value = e.maximal
+	case "null":
+		value = nil
+	default:
+		return nil, false, nil
+	}
+	return value, true, err
+}
+
+// typeOfSchema returns the type of the instances of a schema, if known
+func typeOfSchema(schema map[string]interface{}) string {
+	switch typed := schema["type"].(type) {
+	case string:
+		return typed
+	case []interface{}:
+		for _, item := range typed {
+			if item != "null" {
+				return fmt.Sprint(item)
+			}
+		}
+	}
+	switch {
+	case schema["properties"] != nil || schema["additionalProperties"] != nil:
+		return "object"
+	case schema["items"] != nil:
+		return "array"
+	}
+	return Empty
+}
+
+// reference returns an example instance of a definition of the document. A definition
+// that is already being expanded only gets its required content, to end the recursion.
+func (e *exampleGenerator) reference(ref string) (interface{}, error) {
+	docName, definitionName, ok := parseDefinitionRef(ref, e.docName)
+	if !ok || docName != e.docName {
+		return nil, fmt.Errorf("unsupported reference %s", ref)
+	}
+	schema, found := definitionsOf(e.doc)[definitionName].(map[string]interface{})
+	if !found {
+		return nil, fmt.Errorf("unresolved reference to definition %s", definitionName)
+	}
+	if e.expanding[definitionName] > 0 {
+		if !e.maximal {
+			return nil, fmt.Errorf("definition %s requires an instance of itself", definitionName)
+		}
+		e.maximal = false
+		defer func() { e.maximal = true }()
+	}
+	e.expanding[definitionName]++
+	defer func() { e.expanding[definitionName]-- }()
+	return e.value(schema)
+}
+
+// allOf merges the example instances of the schemas of allOf with the one of the schema itself
+func (e *exampleGenerator) allOf(schema map[string]interface{}, items []interface{}) (interface{}, error) {
+	own := make(map[string]interface{}, len(schema))
+	for keyword, value := range schema {
+		if keyword != "allOf" {
+			own[keyword] = value
+		}
+	}
+	merged, err := e.value(own)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		itemSchema, _ := item.(map[string]interface{})
+		value, err := e.value(itemSchema)
+		if err != nil {
+			return nil, err
+		}
+		mergedObject, isObject := merged.(map[string]interface{})
+		valueObject, valueIsObject := value.(map[string]interface{})
+		if !isObject || !valueIsObject {
+			merged = value
+			continue
+		}
+		for key, item := range valueObject {
+			mergedObject[key] = item
+		}
+	}
+	return merged, nil
+}
+
+// conditional returns an example instance of a schema with if, that is also an example
+// instance of its then schema if it matches the if schema, or of its else schema otherwise
+func (e *exampleGenerator) conditional(schema map[string]interface{}) (interface{}, error) {
+	own := make(map[string]interface{}, len(schema))
+	for keyword, value := range schema {
+		if keyword != ifKeyword && keyword != thenKeyword && keyword != elseKeyword {
+			own[keyword] = value
+		}
+	}
+	value, err := e.value(own)
+	if err != nil {
+		return nil, err
+	}
+	ifSchema, _ := schema[ifKeyword].(map[string]interface{})
+	branchKeyword := elseKeyword
+	if e.matches(value, ifSchema) {
+		branchKeyword = thenKeyword
+	}
+	branch, ok := schema[branchKeyword].(map[string]interface{})
+	if !ok {
+		return value, nil
+	}
+	return e.value(mergeSchemas(own, branch))
+}
+
+// negated returns an example instance of a schema with not, that does not match the
+// not schema. The instance of the other example is tried next and, for numbers, the
+// next number.
+func (e *exampleGenerator) negated(schema, not map[string]interface{}) (interface{}, error) {
+	own := make(map[string]interface{}, len(schema))
+	for keyword, value := range schema {
+		if keyword != notKeyword {
+			own[keyword] = value
+		}
+	}
+	value, err := e.value(own)
+	if err != nil || !e.matches(value, not) {
+		return value, err
+	}
+	e.maximal = !e.maximal
+	value, err = e.value(own)
+	e.maximal = !e.maximal
+	if err != nil || !e.matches(value, not) {
+		return value, err
+	}
+	if number, ok := numberOf(value); ok {
+		own["minimum"] = number
+		own["exclusiveMinimum"] = true
+		if value, err = e.value(own); err != nil || !e.matches(value, not) {
+			return value, err
+		}
+	}
+	return nil, fmt.Errorf("no example found that does not match the not schema")
+}
+
+// matches tells if an instance is valid against a schema of the document
+func (e *exampleGenerator) matches(instance interface{}, schema map[string]interface{}) bool {
+	matching := document{"allOf": []interface{}{schema}}
+	for _, keyword := range []string{definitionsKeyword, defsKeyword} {
+		if definitions, ok := e.doc[keyword]; ok {
+			matching[keyword] = definitions
+		}
+	}
+	return validateExample(matching, instance) == nil
+}
+
+// mergeSchemas returns a schema with the keywords of both schemas, for example instances
+// that are valid against both. Their properties and required properties are merged, and
+// the other keywords of the first schema are kept.
+func mergeSchemas(schema, other map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(schema)+len(other))
+	for keyword, value := range other {
+		merged[keyword] = value
+	}
+	for keyword, value := range schema {
+		merged[keyword] = value
+	}
+	properties := make(map[string]interface{})
+	for _, source := range []map[string]interface{}{other, schema} {
+		sourceProperties, _ := source["properties"].(map[string]interface{})
+		for name, property := range sourceProperties {
+			properties[name] = property
+		}
+	}
+	if len(properties) > 0 {
+		merged["properties"] = properties
+	}
+	var required []interface{}
+	for _, source := range []map[string]interface{}{schema, other} {
+		sourceRequired, _ := source["required"].([]interface{})
+		required = append(required, sourceRequired...)
+	}
+	if len(required) > 0 {
+		merged["required"] = required
+	}
+	return merged
+}
+
+// object returns an example object with the required properties or, for the maximal
+// example, with every property and an entry for additional properties
+func (e *exampleGenerator) object(schema map[string]interface{}) (interface{}, error) {
+	out, err := e.properties(schema)
+	if err != nil {
+		return nil, err
+	}
+	if values, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+		if err := e.additionalProperties(schema, values, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// properties returns the properties of an example object: the required ones or, for
+// the maximal example, all of them
+func (e *exampleGenerator) properties(schema map[string]interface{}) (map[string]interface{}, error) {
+	// the first branch of oneOf or anyOf, like a union member, decides on more properties
+	branch := firstBranch(schema)
+	isRequired := requiredProperties(schema, branch)
+	excluded := excludedProperties(branch)
+	pinned, _ := branch["properties"].(map[string]interface{})
+
+	out := make(map[string]interface{})
+	properties, _ := schema["properties"].(map[string]interface{})
+	if !e.maximal {
+		requireDependents(schema, isRequired)
+	}
+	for _, name := range sortedKeys(properties) {
+		if (!e.maximal && !isRequired[name]) || excluded[name] {
+			continue
+		}
+		pinnedProperty, _ := pinned[name].(map[string]interface{})
+		if value, ok := pinnedProperty[constKeyword]; ok {
+			out[name] = value
+			continue
+		}
+		property, _ := properties[name].(map[string]interface{})
+		value, err := e.value(property)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
+// requiredProperties returns the properties required by a schema or by its first branch
+func requiredProperties(schema, branch map[string]interface{}) map[string]bool {
+	isRequired := make(map[string]bool)
+	for _, source := range []map[string]interface{}{schema, branch} {
+		required, _ := source["required"].([]interface{})
+		for _, name := range required {
+			isRequired[fmt.Sprint(name)] = true
+		}
+	}
+	return isRequired
+}
+
+// additionalProperties adds to an example object the entries that minProperties requires
+// or, for the maximal example, at least one entry
+func (e *exampleGenerator) additionalProperties(schema, values, out map[string]interface{}) error {
+	entries := 0
+	if minProperties, ok := numberOf(schema["minProperties"]); ok {
+		entries = int(minProperties) - len(out)
+	}
+	if e.maximal && entries < 1 {
+		entries = 1
+	}
+	keyPrefix := "key"
+	if names, ok := schema[propertyNamesKeyword].(map[string]interface{}); ok {
+		// property names are strings, whether their schema says so or not
+		name, err := e.value(mergeSchemas(names, map[string]interface{}{"type": "string"}))
+		if err != nil {
+			return fmt.Errorf("property names: %w", err)
+		}
+		keyPrefix = fmt.Sprint(name)
+	}
+	for i := 1; i <= entries; i++ {
+		key := keyPrefix
+		if i > 1 {
+			key = fmt.Sprintf("%s%d", keyPrefix, i)
+		}
+		value, err := e.value(values)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		out[key] = value
+	}
+	return nil
+}
+
+// requireDependents adds to the required properties those that dependentRequired, or
+// the required properties of dependentSchemas, require along with a required property
+func requireDependents(schema map[string]interface{}, isRequired map[string]bool) {
+	dependentRequired, _ := schema[dependentRequiredKeyword].(map[string]interface{})
+	dependentSchemas, _ := schema[dependentSchemasKeyword].(map[string]interface{})
+	for added := true; added; {
+		added = false
+		names := make([]string, 0, len(isRequired))
+		for name := range isRequired {
+			names = append(names, name)
+		}
+		for _, name := range names {
+			dependents, _ := dependentRequired[name].([]interface{})
+			dependentSchema, _ := dependentSchemas[name].(map[string]interface{})
+			required, _ := dependentSchema["required"].([]interface{})
+			for _, dependent := range append(dependents, required...) {
+				if !isRequired[fmt.Sprint(dependent)] {
+					isRequired[fmt.Sprint(dependent)] = true
+					added = true
+				}
+			}
+		}
+	}
+}
+
+// firstBranch returns the first schema of the oneOf or anyOf of a schema
+func firstBranch(schema map[string]interface{}) map[string]interface{} {
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if branches, ok := schema[keyword].([]interface{}); ok && len(branches) > 0 {
+			branch, _ := branches[0].(map[string]interface{})
+			return branch
+		}
+	}
+	return nil
+}
+
+// excludedProperties returns the properties that a schema forbids with not,
+// as the branches of unions forbid the other members
+func excludedProperties(schema map[string]interface{}) map[string]bool {
+	excluded := make(map[string]bool)
+	not, _ := schema["not"].(map[string]interface{})
+	alternatives, _ := not["anyOf"].([]interface{})
+	for _, alternative := range append(alternatives, not) {
+		required, _ := alternative.(map[string]interface{})["required"].([]interface{})
+		for _, name := range required {
+			excluded[fmt.Sprint(name)] = true
+		}
+	}
+	return excluded
+}
+
+// array returns an example array with the minimum number of items or, for the
+// maximal example, with at least one item
+func (e *exampleGenerator) array(schema map[string]interface{}) (interface{}, error) {
+	count := 0
+	if minItems, ok := numberOf(schema["minItems"]); ok {
+		count = int(minItems)
+	}
+	if e.maximal && count < 1 {
+		count = 1
+		if maxItems, ok := numberOf(schema["maxItems"]); ok && maxItems < 1 {
+			count = 0
+		}
+	}
+	items, _ := schema["items"].(map[string]interface{})
+	// the first items are also valid against contains, as many as minContains requires
+	contains, hasContains := schema[containsKeyword].(map[string]interface{})
+	matches := 0
+	if hasContains {
+		matches = 1
+		if minContains, ok := numberOf(schema[minContainsKeyword]); ok {
+			matches = int(minContains)
+		}
+		if count < matches {
+			count = matches
+		}
+	}
+	out := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		itemSchema := items
+		if i < matches {
+			itemSchema = mergeSchemas(contains, items)
+		}
+		value, err := e.value(itemSchema)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, value)
+	}
+	return out, nil
+}
+
+// numberRange is the range of the numbers a schema accepts
+type numberRange struct {
+	minimum, maximum       float64
+	hasMinimum, hasMaximum bool
+}
+
+// rangeOf returns the range of the numbers a schema accepts. Exclusive bounds are
+// moved inside the range, by one for integers.
+func rangeOf(schema map[string]interface{}, integer bool) numberRange {
+	r := numberRange{}
+	r.minimum, r.hasMinimum = numberOf(schema["minimum"])
+	r.maximum, r.hasMaximum = numberOf(schema["maximum"])
+	step := 1.0
+	if !integer && r.hasMinimum && r.hasMaximum {
+		step = (r.maximum - r.minimum) / 2
+	}
+	if r.hasMinimum && schema["exclusiveMinimum"] == true {
+		r.minimum += step
+	}
+	if r.hasMaximum && schema["exclusiveMaximum"] == true {
+		r.maximum -= step
+	}
+	return r
+}
+
+// pick returns the upper bound for the maximal example and the lower bound otherwise,
+// or the number in the range closest to zero
+func (r numberRange) pick(maximal bool) float64 {
+	switch {
+	case maximal && r.hasMaximum:
+		return r.maximum
+	case !maximal && r.hasMinimum:
+		return r.minimum
+	case r.hasMinimum && r.minimum > 0:
+		return r.minimum
+	case r.hasMaximum && r.maximum < 0:
+		return r.maximum
+	}
+	return 0
+}
+
+// number returns an example number within the bounds of the schema. The minimal
+// example takes the lower bound and the maximal example the upper bound, if any.
+func (e *exampleGenerator) number(schema map[string]interface{}, integer bool) interface{} {
+	r := rangeOf(schema, integer)
+	value := r.pick(e.maximal)
+	if integer {
+		value = roundWithin(value, 1, r.maximum, r.hasMaximum)
+	}
+	if multipleOf, ok := numberOf(schema["multipleOf"]); ok && multipleOf > 0 {
+		value = roundWithin(value, multipleOf, r.maximum, r.hasMaximum)
+	}
+	if !integer {
+		return value
+	}
+	// the bounds of 64 bits integers are exact in the schema, but not as float64
+	for _, bound := range []interface{}{schema["minimum"], schema["maximum"]} {
+		if exact, ok := bound.(json.Number); ok {
+			if f, err := exact.Float64(); err == nil && f == value {
+				return exact
+			}
+		}
+	}
+	return int64(value)
+}
+
+// roundWithin rounds a value up to a multiple of unit, or down if that exceeds the maximum
+func roundWithin(value, unit, maximum float64, hasMaximum bool) float64 {
+	rounded := math.Ceil(value/unit) * unit
+	if hasMaximum && rounded > maximum {
+		rounded = math.Floor(value/unit) * unit
+	}
+	return rounded
+}
+
+// numberOf returns the value of a numeric keyword
+func numberOf(value interface{}) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case interface{ Float64() (float64, error) }:
+		f, err := typed.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// stringExample returns an example string matching the pattern or the format of the
+// schema if any, or a placeholder, fitted to its length bounds
+func stringExample(schema map[string]interface{}) (interface{}, error) {
+	minLength, maxLength := 0, -1
+	if bound, ok := numberOf(schema["minLength"]); ok {
+		minLength = int(bound)
+	}
+	if bound, ok := numberOf(schema["maxLength"]); ok {
+		maxLength = int(bound)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		return patternExample(pattern, minLength, maxLength)
+	}
+	if format, ok := schema["format"].(string); ok {
+		if example, known := formatExamples[format]; known {
+			return example, nil
+		}
+	}
+	example := exampleString
+	if len(example) < minLength {
+		example += strings.Repeat("x", minLength-len(example))
+	}
+	if maxLength >= 0 && len(example) > maxLength {
+		example = example[:maxLength]
+	}
+	return example, nil
+}
+
+// patternExample returns a string matching a regular expression, with at least minLength
+// characters and, unless maxLength is negative, at most maxLength characters. The shortest
+// match is lengthened by repeating its repeatable parts, or by padding where the pattern
+// is not anchored.
+func patternExample(pattern string, minLength, maxLength int) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return Empty, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	re = re.Simplify()
+	shortest := &matchWriter{}
+	if err := shortest.write(re); err != nil {
+		return Empty, fmt.Errorf("pattern %q: %w", pattern, err)
+	}
+	match := &matchWriter{extra: minLength - utf8.RuneCountInString(shortest.out.String())}
+	if err := match.write(re); err != nil {
+		return Empty, fmt.Errorf("pattern %q: %w", pattern, err)
+	}
+	example := padMatch(pattern, match.out.String(), minLength)
+	if length := utf8.RuneCountInString(example); length < minLength || (maxLength >= 0 && length > maxLength) {
+		return Empty, fmt.Errorf("no match of pattern %q found within the length bounds", pattern)
+	}
+	return example, nil
+}
+
+// padMatch pads a match of a pattern to the minimum length, if the pattern still matches
+func padMatch(pattern, match string, minLength int) string {
+	missing := minLength - utf8.RuneCountInString(match)
+	re, err := regexp.Compile(pattern)
+	if missing <= 0 || err != nil {
+		return match
+	}
+	padding := strings.Repeat("x", missing)
+	for _, padded := range []string{match + padding, padding + match} {
+		if re.MatchString(padded) {
+			return padded
+		}
+	}
+	return match
+}
+
+// matchWriter writes the shortest string matched by a regular expression, with extra
+// characters from additional repetitions of its repeated parts
+type matchWriter struct {
+	out strings.Builder
+	// extra is the number of characters still to add by repetitions
+	extra int
+}
+
+// write writes a match of a regular expression
+func (w *matchWriter) write(re *syntax.Regexp) error {
+	if minimum, maximum, repeated := repetitionsOf(re); repeated {
+		return w.repeat(re.Sub[0], minimum, maximum)
+	}
+	switch re.Op {
+	case syntax.OpLiteral:
+		w.out.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		w.out.WriteRune(classExample(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		w.out.WriteRune('a')
+	case syntax.OpCapture, syntax.OpAlternate:
+		return w.write(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := w.write(sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpNoMatch:
+		return fmt.Errorf("no string matches")
+	}
+	// empty matches and assertions add nothing
+	return nil
+}
+
+// repeat writes the minimum number of matches of a repeated expression, and more
+// while extra characters are needed and the maximum, unless negative, allows
+func (w *matchWriter) repeat(re *syntax.Regexp, minimum, maximum int) error {
+	for i := 0; i < minimum; i++ {
+		if err := w.write(re); err != nil {
+			return err
+		}
+	}
+	for i := minimum; w.extra > 0 && (maximum < 0 || i < maximum); i++ {
+		before := utf8.RuneCountInString(w.out.String())
+		if err := w.write(re); err != nil {
+			return err
+		}
+		added := utf8.RuneCountInString(w.out.String()) - before
+		if added == 0 {
+			break
+		}
+		w.extra -= added
+	}
+	return nil
+}
+
+// repetitionsOf returns the minimum and maximum, negative if unbounded, numbers of
+// repetitions of an expression that repeats its sub-expression
+func repetitionsOf(re *syntax.Regexp) (minimum, maximum int, repeated bool) {
+	switch re.Op {
+	case syntax.OpStar:
+		return 0, -1, true
+	case syntax.OpPlus:
+		return 1, -1, true
+	case syntax.OpQuest:
+		return 0, 1, true
+	case syntax.OpRepeat:
+		return re.Min, re.Max, true
+	}
+	return 0, 0, false
+}
+
+// classExample picks a character of a character class given as rune ranges
+func classExample(ranges []rune) rune {
+	for _, r := range preferredRunes {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return r
+			}
+		}
+	}
+	return ranges[0]
+}
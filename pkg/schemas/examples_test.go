@@ -0,0 +1,108 @@
+package schemas
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+func TestExamples(t *testing.T) {
+	documents := generate(t, Generator{Examples: true, Format: FormatYAML}, "../../testPkgs/fybrikobject")
+	minimal, ok := documents["sample_crd.example.min.yaml"]
+	if !ok {
+		t.Fatal("minimal example is missing")
+	}
+	if field1, ok := minimal["field1"].(map[string]interface{}); !ok || len(field1) != 0 {
+		t.Errorf("unexpected minimal example %v", minimal)
+	}
+
+	maximal := documents["sample_crd.example.max.yaml"]
+	type1f1, ok := maximal["field1"].(map[string]interface{})["type1f1"].(map[string]interface{})
+	if !ok || type1f1["schemaf1"] != true || type1f1["schemaf2"] != exampleString {
+		t.Errorf("unexpected maximal example %v", maximal)
+	}
+}
+
+func TestExampleConstraints(t *testing.T) {
+	var doc document
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["kind", "name", "count", "ratio", "tags", "node"],
+		"properties": {
+			"kind": {"type": "string", "enum": ["A", "B"]},
+			"name": {"type": "string", "pattern": "^[a-z]{3}-[0-9]+$"},
+			"count": {"type": "integer", "minimum": 3, "maximum": 10, "exclusiveMaximum": true, "multipleOf": 2},
+			"ratio": {"type": "number", "minimum": 0, "maximum": 1, "exclusiveMinimum": true},
+			"tags": {"type": "array", "minItems": 2, "items": {"type": "string", "minLength": 10}},
+			"created": {"type": "string", "format": "date-time"},
+			"labels": {"type": "object", "additionalProperties": {"type": "string", "maxLength": 3}},
+			"node": {"$ref": "#/definitions/Node"}
+		},
+		"definitions": {
+			"Node": {
+				"type": "object",
+				"properties": {"children": {"type": "array", "items": {"$ref": "#/definitions/Node"}}}
+			}
+		}
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, maximal := range []bool{false, true} {
+		e := &exampleGenerator{docName: "doc.json", doc: doc, maximal: maximal, expanding: make(map[string]int)}
+		value, err := e.value(doc)
+		if err != nil {
+			t.Fatalf("maximal %v: %v", maximal, err)
+		}
+		if err := validateExample(doc, value); err != nil {
+			t.Errorf("maximal %v: %v", maximal, err)
+		}
+		example := value.(map[string]interface{})
+		if example["kind"] != "A" || !regexp.MustCompile(`^[a-z]{3}-[0-9]+$`).MatchString(example["name"].(string)) {
+			t.Errorf("maximal %v: unexpected strings %v", maximal, example)
+		}
+		if _, hasLabels := example["labels"]; hasLabels != maximal {
+			t.Errorf("maximal %v: unexpected optional properties %v", maximal, example)
+		}
+	}
+}
+
+func TestExampleRequiresItself(t *testing.T) {
+	doc := document{
+		"$ref": "#/definitions/Node",
+		"definitions": map[string]interface{}{
+			"Node": map[string]interface{}{
+				"type":       "object",
+				"required":   []interface{}{"next"},
+				"properties": map[string]interface{}{"next": map[string]interface{}{"$ref": "#/definitions/Node"}},
+			},
+		},
+	}
+	e := &exampleGenerator{docName: "doc.json", doc: doc, expanding: make(map[string]int)}
+	if _, err := e.value(doc); err == nil {
+		t.Error("expected an error for a definition that requires itself")
+	}
+}
+
+func TestPatternExampleLength(t *testing.T) {
+	for _, test := range []struct {
+		pattern              string
+		minLength, maxLength int
+		expected             string
+	}{
+		{"^[a-z]+$", 5, -1, "aaaaa"},
+		{"^[a-z]{3}-[0-9]+$", 8, 8, "aaa-0000"},
+		{"^a(bc)*$", 4, 6, "abcbc"},
+		{"ab", 4, -1, "abxx"},
+		{"^ab$", 4, -1, Empty},
+		{"^[a-z]{3}$", 0, 2, Empty},
+	} {
+		example, err := patternExample(test.pattern, test.minLength, test.maxLength)
+		if test.expected == Empty && err == nil {
+			t.Errorf("%s: expected an error, got %q", test.pattern, example)
+		} else if example != test.expected {
+			t.Errorf("%s: unexpected example %q (%v)", test.pattern, example, err)
+		}
+	}
+}
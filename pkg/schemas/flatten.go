@@ -0,0 +1,118 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strconv"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const flattenMarkerName = "fybrik:validation:flatten"
+
+// flattenMarker merges the properties of an inline field into the struct that embeds it
+var flattenMarker = markers.Must(markers.MakeDefinition(flattenMarkerName, markers.DescribesField, struct{}{}))
+
+// flattens tells if an inline field is merged into its struct rather than added to its allOf
+func flattens(ctx *schemaContext, field markers.FieldInfo) bool {
+	return ctx.flattenEmbedded || field.Markers.Get(flattenMarkerName) != nil
+}
+
+// flattenEmbedded merges the properties and required properties of the types of inline fields
+// into the schema of the struct embedding them. Their other constraints are kept in its allOf.
+// The keywords of the merged subschemas are copied to their new place. The properties of
+// embedded pointers are not required, as they are left out when the pointer is nil.
+func flattenEmbedded(ctx *schemaContext, props *apiext.JSONSchemaProps, fields []markers.FieldInfo) {
+	for _, field := range fields {
+		typeIdent, isNamed := namedTypeIdent(ctx, field.RawField.Type)
+		if !isNamed {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("can only flatten named struct types, not %s",
+				types.ExprString(field.RawField.Type)), field.RawField))
+			continue
+		}
+		ctx.requestSchema(typeIdent)
+		schema, known := ctx.schemaRequester.SchemaFor(typeIdent)
+		if !known || schema.Type != "object" {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("can not flatten %s, it is not a struct or embeds %s",
+				typeIdent.Name, ctx.info.Name), field.RawField))
+			continue
+		}
+
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		keywords := ctx.schemaRequester.KeywordsFor(typeIdent)
+		for _, name := range names {
+			if _, exists := props.Properties[name]; exists {
+				ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("property %q of embedded %s conflicts with a property of %s",
+					name, typeIdent.Name, ctx.info.Name), field.RawField))
+				continue
+			}
+			props.Properties[name] = schema.Properties[name]
+			keywords.copyTo(ctx.keywords, pointerTo("properties", name), ctx.path+pointerTo("properties", name))
+		}
+		expr, _ := ctx.resolve(field.RawField.Type)
+		if _, isPointer := expr.(*ast.StarExpr); isPointer {
+			schema.Required = nil
+		}
+		for _, name := range schema.Required {
+			if indexOf(name, props.Required) == -1 {
+				props.Required = append(props.Required, name)
+			}
+		}
+		for i := range schema.AllOf {
+			keywords.copyTo(ctx.keywords, pointerTo("allOf", strconv.Itoa(i)), ctx.path+pointerTo("allOf", strconv.Itoa(len(props.AllOf)+i)))
+		}
+		props.AllOf = append(props.AllOf, schema.AllOf...)
+		if len(schema.OneOf) > 0 {
+			keywords.copyTo(ctx.keywords, pointerTo("oneOf"), ctx.path+pointerTo("allOf", strconv.Itoa(len(props.AllOf)), "oneOf"))
+			props.AllOf = append(props.AllOf, apiext.JSONSchemaProps{OneOf: schema.OneOf})
+		}
+	}
+}
+
+// namedTypeIdent returns the named type of a type expression, such as the type of an inline field
+func namedTypeIdent(ctx *schemaContext, expr ast.Expr) (crd.TypeIdent, bool) {
+	expr, ctx = ctx.resolve(expr)
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return namedTypeIdent(ctx, expr.X)
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		typeIdent, err := instanceIdentFor(ctx, expr)
+		return typeIdent, err == nil
+	case *ast.Ident, *ast.SelectorExpr:
+		named, isNamed := ctx.pkg.TypesInfo.TypeOf(expr).(*types.Named)
+		if !isNamed || named.Obj().Pkg() == nil {
+			return crd.TypeIdent{}, false
+		}
+		pkgPath := loader.NonVendorPath(named.Obj().Pkg().Path())
+		if named.Obj().Pkg() == ctx.pkg.Types {
+			pkgPath = Empty
+		}
+		typeIdent := ctx.typeIdentFor(pkgPath, named.Obj().Name())
+		return typeIdent, typeIdent.Package != nil
+	default:
+		return crd.TypeIdent{}, false
+	}
+}
+
+// SchemaFor returns the schema generated for a type
+func (context *GeneratorContext) SchemaFor(typ crd.TypeIdent) (apiext.JSONSchemaProps, bool) {
+	schema, known := context.parser.Schemata[typ]
+	return schema, known
+}
+
+// KeywordsFor returns the keywords of the schema generated for a type
+func (context *GeneratorContext) KeywordsFor(typ crd.TypeIdent) keywordTable {
+	return context.keywords[typ]
+}
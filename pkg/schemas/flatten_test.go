@@ -0,0 +1,78 @@
+package schemas
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+)
+
+func TestFlattenMarker(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/embedded")
+	definitions := documents["embedded.json"][definitionsKeyword].(map[string]interface{})
+
+	flat := definitions["FlatResource"].(map[string]interface{})
+	if _, exists := flat["allOf"]; exists {
+		t.Errorf("unexpected allOf %v", flat["allOf"])
+	}
+	var names []string
+	for name := range flat["properties"].(map[string]interface{}) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"labels", "name", "phase", "spec"}) {
+		t.Errorf("unexpected properties %v", names)
+	}
+	// the properties of the embedded *Status are left out when it is nil
+	if !reflect.DeepEqual(flat["required"], []interface{}{"spec", "name"}) {
+		t.Errorf("unexpected required %v", flat["required"])
+	}
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/FlatResource",
+		definitionsKeyword: definitions,
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"name": "a", "spec": "b"}, true},
+		{map[string]interface{}{"name": "a", "spec": "b", "phase": "c"}, true},
+		{map[string]interface{}{"spec": "b", "phase": "c"}, false},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v", test.instance, test.valid)
+		}
+	}
+
+	// without the marker or the option, embedded structs are still an allOf
+	resource := definitions["Resource"].(map[string]interface{})
+	if allOf, ok := resource["allOf"].([]interface{}); !ok || len(allOf) != 1 {
+		t.Errorf("unexpected allOf %v", resource["allOf"])
+	}
+}
+
+func TestFlattenConflict(t *testing.T) {
+	var generator genall.Generator = &Generator{OutputDir: t.TempDir(), Flatten: true}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/embedded")
+	if err != nil {
+		t.Fatalf("could not load roots: %v", err)
+	}
+	if !runtime.Run() {
+		t.Fatal("expected a conflict error")
+	}
+	var messages []string
+	for _, err := range runtime.Roots[0].Errors {
+		messages = append(messages, err.Error())
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "embedded.go:35") ||
+		!strings.Contains(messages[0], `property "name" of embedded Metadata conflicts with a property of Named`) {
+		t.Errorf("unexpected errors %v", messages)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Supported values for Generator.Format
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+const (
+	jsonExtension = ".json"
+	yamlExtension = ".yaml"
+	yamlIndent    = 2
+)
+
+// validateFormat checks that the output format is supported
+func validateFormat(format string) error {
+	switch format {
+	case Empty, FormatJSON, FormatYAML:
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q, expected %s or %s", format, FormatJSON, FormatYAML)
+	}
+}
+
+// fileNameFor returns the name of the file a document is written to in the given format
+func fileNameFor(documentName, format string) string {
+	if format == FormatYAML {
+		return strings.TrimSuffix(documentName, jsonExtension) + yamlExtension
+	}
+	return documentName
+}
+
+// renameDocuments gives the documents the file extension of the given format,
+// and points cross-document references at the renamed files
+func renameDocuments(documents map[string]document, format string) map[string]document {
+	if format != FormatYAML {
+		return documents
+	}
+	renamed := make(map[string]document, len(documents))
+	for docName, doc := range documents {
+		rewriteRefs(doc, func(ref string) string {
+			refDocument, pointer, found := strings.Cut(ref, "#")
+			if _, generated := documents[refDocument]; !found || !generated {
+				return ref
+			}
+			return fileNameFor(refDocument, format) + "#" + pointer
+		})
+		renamed[fileNameFor(docName, format)] = doc
+	}
+	return renamed
+}
+
+// marshalValue encodes a document, or any other generic JSON value, in the given format
+func marshalValue(value interface{}, format string) ([]byte, error) {
+	doc, isDocument := value.(document)
+	if isDocument {
+		value = map[string]interface{}(doc)
+	}
+	if format == FormatYAML {
+		value = yamlValue(value)
+	}
+	// documents are schemas, written with their keywords in order
+	if isDocument {
+		value = orderedSchema(value.(map[string]interface{}))
+	}
+	if format != FormatYAML {
+		return json.MarshalIndent(value, Empty, "  ")
+	}
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(yamlIndent)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// keywordRanks orders the keywords of schemas as the fields of apiext.JSONSchemaProps,
+// the order generated schemas have always been written in
+var keywordRanks = func() map[string]int {
+	ranks := make(map[string]int)
+	propsType := reflect.TypeOf(apiext.JSONSchemaProps{})
+	for i := 0; i < propsType.NumField(); i++ {
+		name, _, _ := strings.Cut(propsType.Field(i).Tag.Get("json"), ",")
+		ranks[name] = i
+	}
+	// keywords of later dialects take the place of those they replace
+	for keyword, replaced := range map[string]string{
+		"$id":              "id",
+		defsKeyword:        definitionsKeyword,
+		"const":            "enum",
+		"examples":         "example",
+		"prefixItems":      "items",
+		"dependentSchemas": "dependencies",
+	} {
+		ranks[keyword] = ranks[replaced]
+	}
+	return ranks
+}()
+
+// orderedSchema is a schema that is encoded with its keywords in the order of keywordRanks,
+// followed by the keywords apiext.JSONSchemaProps has no fields for in alphabetical order
+type orderedSchema map[string]interface{}
+
+// keywords returns the keywords of the schema in the order they are encoded in
+func (schema orderedSchema) keywords() []string {
+	keywords := sortedKeys(schema)
+	sort.SliceStable(keywords, func(i, j int) bool {
+		rankI, knownI := keywordRanks[keywords[i]]
+		rankJ, knownJ := keywordRanks[keywords[j]]
+		if knownI != knownJ {
+			return knownI
+		}
+		return knownI && rankI < rankJ
+	})
+	return keywords
+}
+
+// value returns the value of a keyword, with the schemas it holds ordered too
+func (schema orderedSchema) value(keyword string) interface{} {
+	value := schema[keyword]
+	switch {
+	case keyword == "items" || indexOf(keyword, schemaListKeywords) != -1:
+		if items, ok := value.([]interface{}); ok {
+			ordered := make([]interface{}, len(items))
+			for i, item := range items {
+				ordered[i] = orderedSubschema(item)
+			}
+			return ordered
+		}
+		return orderedSubschema(value)
+	case keyword == "additionalProperties" || indexOf(keyword, schemaKeywords) != -1:
+		return orderedSubschema(value)
+	case keyword == "dependencies" || indexOf(keyword, schemaMapKeywords) != -1:
+		if schemas, ok := value.(map[string]interface{}); ok {
+			ordered := make(map[string]interface{}, len(schemas))
+			for name, subschema := range schemas {
+				ordered[name] = orderedSubschema(subschema)
+			}
+			return ordered
+		}
+	}
+	return value
+}
+
+// orderedSubschema orders a nested schema, leaving boolean schemas and lists as they are
+func orderedSubschema(value interface{}) interface{} {
+	if schema, ok := value.(map[string]interface{}); ok {
+		return orderedSchema(schema)
+	}
+	return value
+}
+
+// MarshalJSON encodes the schema with its keywords in order
+func (schema orderedSchema) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, keyword := range schema.keywords() {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, err := json.Marshal(keyword)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(schema.value(keyword))
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// MarshalYAML encodes the schema as a mapping with its keywords in order
+func (schema orderedSchema) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, keyword := range schema.keywords() {
+		var key, value yaml.Node
+		if err := key.Encode(keyword); err != nil {
+			return nil, err
+		}
+		if err := value.Encode(schema.value(keyword)); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &key, &value)
+	}
+	return node, nil
+}
+
+// yamlValue converts the json.Number values of a generic JSON value to numbers,
+// which the YAML encoder would otherwise write as strings
+func yamlValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			out[key] = yamlValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, item := range typed {
+			out[i] = yamlValue(item)
+		}
+		return out
+	case json.Number:
+		if i, err := typed.Int64(); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(typed.String(), 10, 64); err == nil {
+			return u
+		}
+		if f, err := typed.Float64(); err == nil {
+			return f
+		}
+		return typed.String()
+	default:
+		return value
+	}
+}
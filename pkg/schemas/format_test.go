@@ -0,0 +1,63 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestYAMLFormat(t *testing.T) {
+	documents := generate(t, Generator{Format: FormatYAML}, "../../testPkgs/fybrikobject")
+	for _, name := range []string{"schemapkg.yaml", "external.yaml", "sample_crd.yaml"} {
+		if _, ok := documents[name]; !ok {
+			t.Errorf("document %s is missing", name)
+		}
+	}
+	for name, doc := range documents {
+		rewriteRefs(doc, func(ref string) string {
+			if strings.Contains(ref, jsonExtension) {
+				t.Errorf("%s: reference %s points to a JSON document", name, ref)
+			}
+			return ref
+		})
+	}
+	ref := documents["sample_crd.yaml"]["definitions"].(map[string]interface{})["Type1"].(map[string]interface{})["properties"].(map[string]interface{})["type1f1"].(map[string]interface{})["$ref"]
+	if ref != "schemapkg.yaml#/definitions/SchemaType1" {
+		t.Errorf("unexpected reference %v", ref)
+	}
+}
+
+func TestMarshalYAMLNumbers(t *testing.T) {
+	out, err := marshalValue(document{"minimum": json.Number("1"), "maximum": json.Number("2.5")}, FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "maximum: 2.5\nminimum: 1\n" {
+		t.Errorf("unexpected YAML:\n%s", out)
+	}
+}
+
+func TestMarshalKeywordOrder(t *testing.T) {
+	doc := document{
+		"definitions": map[string]interface{}{"B": map[string]interface{}{"type": "string", "$ref": "#"}, "A": true},
+		"title":       "doc.json",
+		"$comment":    "comment",
+		"const":       "a",
+		"type":        "object",
+		"description": "description",
+	}
+	out, err := marshalValue(doc, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"description":"description","type":"object","title":"doc.json","const":"a",` +
+		`"definitions":{"A":true,"B":{"$ref":"#","type":"string"}},"$comment":"comment"}`
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, out); err != nil {
+		t.Fatal(err)
+	}
+	if compact.String() != expected {
+		t.Errorf("unexpected JSON:\n%s", compact.String())
+	}
+}
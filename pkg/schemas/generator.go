@@ -4,7 +4,6 @@
 package schemas
 
 import (
-	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/types"
@@ -26,6 +25,7 @@ var (
 	externalDocumentName = "external.json"
 	schemaMarker         = markers.Must(markers.MakeDefinition("fybrik:validation:schema", markers.DescribesPackage, struct{}{}))
 	objectMarker         = markers.Must(markers.MakeDefinition("fybrik:validation:object", markers.DescribesType, ObjName(Empty)))
+	helmMarker           = markers.Must(markers.MakeDefinition("fybrik:validation:helm", markers.DescribesType, ChartPath(Empty)))
 )
 
 type ObjName string
@@ -48,24 +48,123 @@ type Generator struct {
 	//
 	// Left unspecified, the default is false
 	AllowDangerousTypes *bool `marker:",optional"`
+
+	// Dialect is the JSON schema draft to emit documents for: draft-07, 2019-09
+	// or 2020-12. Documents then carry $schema and $id, and definitions are kept
+	// under $defs for the drafts that renamed the keyword.
+	//
+	// Left unspecified, documents have no $schema and use definitions
+	Dialect string `marker:",optional"`
+
+	// BaseURI is prepended to the document name to build its $id.
+	// It is only used together with Dialect.
+	BaseURI string `marker:",optional"`
+
+	// OpenAPI is the OpenAPI version (3.0 or 3.1) of a single document to emit
+	// instead of the JSON schema documents. Every generated definition is placed
+	// under components.schemas of that document.
+	OpenAPI string `marker:",optional"`
+
+	// Format is the format documents are written in: json or yaml.
+	// YAML documents get a .yaml extension and are referenced by that name.
+	//
+	// Left unspecified, the default is json
+	Format string `marker:",optional"`
+
+	// Bundle makes each object document self-contained: every definition it
+	// references in other documents is copied into its own definitions.
+	// Only the object documents are written when this is true.
+	Bundle bool `marker:",optional"`
+
+	// Dereference replaces every reference with the schema it references, so that
+	// documents have no $ref at all. Inlined schemas keep the name of their
+	// definition in the x-definition-name annotation.
+	Dereference bool `marker:",optional"`
+
+	// MaxDepth is the number of times a recursive definition is inlined into itself
+	// by Dereference, after which any value is accepted.
+	//
+	// Left unspecified, recursive definitions can not be dereferenced
+	MaxDepth int `marker:",optional"`
+
+	// Examples writes a minimal and a maximal example instance of each type with
+	// object marker next to the documents, as <object>.example.min.json and
+	// <object>.example.max.json (.yaml in the yaml format). The minimal example
+	// only sets what is required.
+	// Examples are validated against their schema before they are written, those that
+	// can not be generated or are not valid are left out with a warning.
+	Examples bool `marker:",optional"`
+
+	// CRD writes the structural openAPIV3Schema of each type with object marker,
+	// as served by a CustomResourceDefinition, to <object>.crd.json (.crd.yaml in the
+	// yaml format) next to the documents.
+	// References are inlined and x-kubernetes-* extensions are kept.
+	CRD bool `marker:",optional"`
+
+	// Flatten merges the properties and required properties of embedded and inline
+	// fields into the struct embedding them, instead of adding their type to its allOf.
+	// Inline fields can also be flattened one by one with the flatten marker.
+	Flatten bool `marker:",optional"`
+
+	// Nullable is the nullability of pointer fields: none, nullable to mark them with
+	// the OpenAPI nullable keyword, or type to add null to their type. The nullable
+	// marker sets the nullability of a field whatever the policy. Only OpenAPI 3.0
+	// documents and CRD schemas keep the nullable keyword, JSON schema documents
+	// always add null to the type.
+	//
+	// Left unspecified, the default is none
+	Nullable string `marker:",optional"`
+
+	// OmitDeprecated leaves the fields with a "Deprecated:" paragraph in their doc
+	// comment out of the schemas. Otherwise they are marked as deprecated.
+	OmitDeprecated bool `marker:",optional"`
+
+	// Profiles writes the create, update and read profiles of each type with object
+	// marker, as the <object>.create.json, <object>.update.json and <object>.read.json
+	// documents (.yaml in the yaml format). The profiles are bundled, and leave out the
+	// read-only properties for create and update and the write-only properties for read.
+	Profiles bool `marker:",optional"`
+
+	// IntegerBounds sets the minimum and maximum of integers to the bounds of their
+	// kind (int8 to int64 and uint8 to uint64), or to those of Minimum and Maximum
+	// markers where they are tighter. Markers that leave no value of the kind are an error.
+	IntegerBounds bool `marker:",optional"`
+
+	// Titles sets the title of the schemas of types and fields to the first sentence
+	// of their doc comment. Descriptions are the whole doc comment, in CommonMark.
+	Titles bool `marker:",optional"`
 }
 
 type GeneratorContext struct {
-	ctx     *genall.GenerationContext
-	parser  *crd.Parser
-	typesOM *orderedmap.OrderedMap[crd.TypeIdent, struct{}]
+	generator Generator
+	ctx       *genall.GenerationContext
+	parser    *crd.Parser
+	typesOM   *orderedmap.OrderedMap[crd.TypeIdent, struct{}]
 	// Array of packages that have a type with object marker
 	objectPkgs []string
+	// Names of the documents generated for types with object marker
+	objectDocuments map[string]bool
+	// Types with helm marker by the path of their chart
+	helmCharts map[string]crd.TypeIdent
+	// Instantiations of generic types by their type identifier
+	instances  map[crd.TypeIdent]instance
 	pkgMarkers map[*loader.Package]markers.MarkerValues
+	// Keywords of the schemas of types that apiext.JSONSchemaProps has no field for
+	keywords map[crd.TypeIdent]keywordTable
+	// Types of the definitions of each document by definition name, and of the
+	// root of object documents under the empty name
+	documentTypes map[string]map[string]crd.TypeIdent
 }
 
 func (Generator) CheckFilter() loader.NodeFilter {
 	return func(node ast.Node) bool {
 		switch node := node.(type) {
-		case *ast.InterfaceType:
-			// skip interfaces, we never care about references in them
-			return false
 		case *ast.Field:
+			// methods and embedded interfaces decide which types implement
+			// an interface, so visit them for the implementations markers
+			if _, isMethod := node.Type.(*ast.FuncType); isMethod || len(node.Names) == 0 {
+				return true
+			}
 			_, hasTag := loader.ParseAstTag(node.Tag).Lookup("json")
 			// fields without JSON tags mean we have custom serialization,
 			// so only visit fields with tags.
@@ -82,13 +181,46 @@ func (Generator) RegisterMarkers(into *markers.Registry) error {
 		return err
 	}
 
-	if err := markers.RegisterAll(into, schemaMarker, objectMarker); err != nil {
+	if err := markers.RegisterAll(into, schemaMarker, objectMarker, helmMarker); err != nil {
 		return err
 	}
+	if err := markers.RegisterAll(into, implementationsMarkers...); err != nil {
+		return err
+	}
+	if err := markers.RegisterAll(into, unionMarkers...); err != nil {
+		return err
+	}
+	if err := markers.RegisterAll(into, flattenMarker, nullableMarker, readOnlyMarker, writeOnlyMarker); err != nil {
+		return err
+	}
+	if err := markers.RegisterAll(into, validationMarkers...); err != nil {
+		return err
+	}
+	for _, marker := range validationMarkers {
+		into.AddHelp(marker, markers.SimpleHelp("object", validationMarkerHelp[marker.Name]))
+	}
 	into.AddHelp(schemaMarker,
 		markers.SimpleHelp("object", "enable generation of JSON schema definition for the go structure"))
 	into.AddHelp(objectMarker,
 		markers.SimpleHelp("object", "enable generation of JSON schema object for the go structure"))
+	into.AddHelp(helmMarker,
+		markers.SimpleHelp("object", "enable generation of a Helm values.schema.json for the go structure at the given chart path"))
+	for _, marker := range implementationsMarkers {
+		into.AddHelp(marker,
+			markers.SimpleHelp("object", "describe an interface as one of its implementations, listed or discovered from the loaded packages"))
+	}
+	into.AddHelp(unionMarkers[0],
+		markers.SimpleHelp("object", "require exactly one of the members of the go structure, all of its fields but the discriminator"))
+	into.AddHelp(unionMarkers[1],
+		markers.SimpleHelp("object", "mark the field holding the name of the member that is set in a union"))
+	into.AddHelp(flattenMarker,
+		markers.SimpleHelp("object", "merge the properties of an embedded or inline field into the go structure"))
+	into.AddHelp(nullableMarker,
+		markers.SimpleHelp("object", "accept null for the field, or not, whatever the nullability of pointer fields"))
+	into.AddHelp(readOnlyMarker,
+		markers.SimpleHelp("object", "mark the field as set by the server, left out of the create and update profiles"))
+	into.AddHelp(writeOnlyMarker,
+		markers.SimpleHelp("object", "mark the field as never returned by the server, left out of the read profile"))
 	return nil
 }
 
@@ -113,6 +245,50 @@ func (context *GeneratorContext) needPackage(pkg *loader.Package) {
 }
 
 func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	context, docs, err := g.generateDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	out := &generation{documents: docs, files: make(map[string]interface{})}
+	for _, step := range context.steps() {
+		if err := step(out); err != nil {
+			return err
+		}
+	}
+
+	if err := g.output(out.documents); err != nil {
+		return err
+	}
+	for fileName, value := range out.files {
+		if err := g.writeFile(fileName, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the values of the generator options
+func (g Generator) validate() error {
+	if err := validateDialect(g.Dialect); err != nil {
+		return err
+	}
+	if err := validateOpenAPI(g.OpenAPI, g.Dialect); err != nil {
+		return err
+	}
+	if err := validateFormat(g.Format); err != nil {
+		return err
+	}
+	return validateNullable(g.Nullable, g.OpenAPI)
+}
+
+// generateDocuments generates the schemas of the types with object marker and of the types
+// in packages with schema marker, and returns them as the documents they are written to.
+// The documents keep x-kubernetes-preserve-unknown-fields, see allowUnknownFields.
+func (g Generator) generateDocuments(ctx *genall.GenerationContext) (*GeneratorContext, map[string]document, error) {
 	parser := &crd.Parser{
 		Collector:           ctx.Collector,
 		Checker:             ctx.Checker,
@@ -121,11 +297,17 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 	crd.AddKnownTypes(parser)
 
 	context := &GeneratorContext{
-		ctx:        ctx,
-		parser:     parser,
-		typesOM:    orderedmap.New[crd.TypeIdent, struct{}](),
-		objectPkgs: []string{},
-		pkgMarkers: make(map[*loader.Package]markers.MarkerValues),
+		generator:       g,
+		ctx:             ctx,
+		parser:          parser,
+		typesOM:         orderedmap.New[crd.TypeIdent, struct{}](),
+		objectPkgs:      []string{},
+		objectDocuments: make(map[string]bool),
+		helmCharts:      make(map[string]crd.TypeIdent),
+		instances:       make(map[crd.TypeIdent]instance),
+		pkgMarkers:      make(map[*loader.Package]markers.MarkerValues),
+		keywords:        make(map[crd.TypeIdent]keywordTable),
+		documentTypes:   make(map[string]map[string]crd.TypeIdent),
 	}
 
 	// Load input packages
@@ -139,15 +321,36 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 		context.pkgMarkers[root] = pkgMarkers
 	}
 
-	// Scan loaded types
+	context.scanTypes()
+	documents := context.assembleDocuments()
+
+	docs, err := context.toDocuments(documents)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlinkUnresolved(docs)
+	return context, docs, nil
+}
+
+// scanTypes requests the schemas of the loaded types with object or helm marker,
+// and of the types of packages with schema marker
+func (context *GeneratorContext) scanTypes() {
 	for pair := context.typesOM.Oldest(); pair != nil; pair = pair.Next() {
 		typeIdent := pair.Key
-		info, knownInfo := parser.Types[typeIdent]
+		info, knownInfo := context.parser.Types[typeIdent]
 		if knownInfo {
 			if info.Markers.Get(objectMarker.Name) != nil {
 				context.objectPkgs = append(context.objectPkgs, typeIdent.Package.PkgPath)
 				context.NeedSchemaFor(typeIdent)
 			}
+			if chartPath, isValues := info.Markers.Get(helmMarker.Name).(ChartPath); isValues {
+				if other, exists := context.helmCharts[string(chartPath)]; exists {
+					typeIdent.Package.AddError(loader.ErrFromNode(
+						fmt.Errorf("chart %s already has the values of %s", chartPath, other), info.RawSpec))
+				}
+				context.helmCharts[string(chartPath)] = typeIdent
+				context.NeedSchemaFor(typeIdent)
+			}
 		}
 		if pkgMarkers, hasMarkers := context.pkgMarkers[typeIdent.Package]; hasMarkers {
 			if pkgMarkers.Get(schemaMarker.Name) != nil {
@@ -157,10 +360,14 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 			}
 		}
 	}
+}
 
+// assembleDocuments places the generated schemas in the documents they are written to:
+// the document of their package, or external.json, and the object documents
+func (context *GeneratorContext) assembleDocuments() map[string]*apiext.JSONSchemaProps {
 	documents := make(map[string]*apiext.JSONSchemaProps)
 	//nolint:gocritic
-	for typeIdent, typeSchema := range parser.Schemata {
+	for typeIdent, typeSchema := range context.parser.Schemata {
 		documentName := context.documentNameFor(typeIdent.Package)
 		document, exists := documents[documentName]
 		if !exists {
@@ -168,36 +375,118 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 				Title:       documentName,
 				Definitions: make(apiext.JSONSchemaDefinitions),
 			}
+			// the package doc comment describes the document of a package with schema marker
+			if documentName != externalDocumentName {
+				pkgCtx := newSchemaContext(typeIdent.Package, context, context.parser.AllowDangerousTypes)
+				document.Description = pkgCtx.markdown(pkgCtx.parseDoc(packageDoc(typeIdent.Package)))
+			}
 			documents[documentName] = document
+			context.documentTypes[documentName] = make(map[string]crd.TypeIdent)
 		}
 		document.Definitions[context.definitionNameFor(documentName, typeIdent)] = typeSchema
+		context.documentTypes[documentName][context.definitionNameFor(documentName, typeIdent)] = typeIdent
 
 		// Generate a schema for types with "fybrik:validation:object" marker
-		info, knownInfo := parser.Types[typeIdent]
-		if knownInfo {
-			if info.Markers.Get(objectMarker.Name) != nil {
-				listFields, _ := context.getFields(typeIdent)
-				schemaPtr := parser.Schemata[typeIdent]
-				documentName := fmt.Sprintf("%s.json", schemaPtr.Title)
-				document, exists := documents[documentName]
-				context.removeExtraProps(typeIdent, &schemaPtr, &listFields)
-				if !exists {
-					document = schemaPtr.DeepCopy()
-					document.Title = documentName
-					document.Definitions = make(apiext.JSONSchemaDefinitions)
-					documents[documentName] = document
-				}
+		info, knownInfo := context.parser.Types[typeIdent]
+		if knownInfo && info.Markers.Get(objectMarker.Name) != nil {
+			context.addObjectDocument(documents, typeIdent, &typeSchema)
+		}
+	}
+	return documents
+}
 
-				for _, fieldType := range listFields {
-					typeSchemaField := parser.Schemata[fieldType]
-					context.removeExtraProps(fieldType, &typeSchemaField, &listFields)
-					document.Definitions[context.definitionNameFor(documentName, fieldType)] = typeSchemaField
+// addObjectDocument adds to the object document of a type with object marker its schema
+// and the definitions of its fields, without the fields that are not part of the taxonomy
+func (context *GeneratorContext) addObjectDocument(documents map[string]*apiext.JSONSchemaProps,
+	typeIdent crd.TypeIdent, typeSchema *apiext.JSONSchemaProps) {
+	listFields, _ := context.getFields(typeIdent)
+	// removeExtraProps changes the schema, so work on a copy to keep
+	// the definition in the package document intact: it used to drop the
+	// properties of the definition and repeat entries of its required list
+	schemaPtr := *typeSchema.DeepCopy()
+	documentName := fmt.Sprintf("%s.json", schemaPtr.Title)
+	context.objectDocuments[documentName] = true
+	document, exists := documents[documentName]
+	context.removeExtraProps(typeIdent, &schemaPtr, &listFields)
+	if !exists {
+		document = schemaPtr.DeepCopy()
+		document.Title = documentName
+		document.Definitions = make(apiext.JSONSchemaDefinitions)
+		documents[documentName] = document
+		context.documentTypes[documentName] = map[string]crd.TypeIdent{Empty: typeIdent}
+	}
+
+	for _, fieldType := range listFields {
+		fieldSchema := context.parser.Schemata[fieldType]
+		typeSchemaField := *fieldSchema.DeepCopy()
+		context.removeExtraProps(fieldType, &typeSchemaField, &listFields)
+		document.Definitions[context.definitionNameFor(documentName, fieldType)] = typeSchemaField
+		context.documentTypes[documentName][context.definitionNameFor(documentName, fieldType)] = fieldType
+	}
+}
+
+// toDocuments converts the generated documents to their generic JSON form
+func (context *GeneratorContext) toDocuments(documents map[string]*apiext.JSONSchemaProps) (map[string]document, error) {
+	qualifiedRefs := context.qualifiedRefs()
+	docs := make(map[string]document)
+	for docName, props := range documents {
+		doc, err := toDocument(props)
+		if err != nil {
+			return nil, err
+		}
+		if err := context.applyKeywords(docName, doc); err != nil {
+			return nil, err
+		}
+		if !context.objectDocuments[docName] {
+			rewriteRefs(doc, func(ref string) string {
+				if docName != externalDocumentName && strings.HasPrefix(ref, "#") {
+					return ref
 				}
-			}
+				if qualifiedRef, ok := qualifiedRefs[ref]; ok {
+					return qualifiedRef
+				}
+				return ref
+			})
+		}
+		docs[docName] = doc
+	}
+	return docs, nil
+}
+
+// qualifiedRefs maps the references to the types of packages with an object marker to
+// the qualified definition names of these types in the external document. Such types are
+// referenced by their plain name (see TypeRefLink), which only resolves in the object
+// documents, so the references of the other documents must be pointed at the qualified name.
+func (context *GeneratorContext) qualifiedRefs() map[string]string {
+	prefix := "#/" + definitionsKeywordFor(context.generator.Dialect) + "/"
+	qualifiedRefs := make(map[string]string)
+	for typeIdent := range context.parser.Schemata {
+		if indexOf(typeIdent.Package.PkgPath, context.objectPkgs) != -1 &&
+			context.documentNameFor(typeIdent.Package) == externalDocumentName {
+			qualifiedName := context.definitionNameFor(externalDocumentName, typeIdent)
+			qualifiedRefs[prefix+typeIdent.Name] = prefix + qualifiedName
+			qualifiedRefs[externalDocumentName+prefix+typeIdent.Name] = externalDocumentName + prefix + qualifiedName
 		}
 	}
+	return qualifiedRefs
+}
 
-	return g.output(documents)
+// applyKeywords sets, in a generated document, the keywords of the schemas of the types
+// of its definitions and of its root
+func (context *GeneratorContext) applyKeywords(docName string, doc document) error {
+	definitions, _ := doc[definitionsKeyword].(map[string]interface{})
+	for definitionName, typeIdent := range context.documentTypes[docName] {
+		schema := map[string]interface{}(doc)
+		if definitionName != Empty {
+			if schema, _ = definitions[definitionName].(map[string]interface{}); schema == nil {
+				continue
+			}
+		}
+		if err := applyKeywords(schema, context.keywords[typeIdent]); err != nil {
+			return fmt.Errorf("invalid keywords of %s: %w", typeIdent, err)
+		}
+	}
+	return nil
 }
 
 // Get the fields that related to taxonomy (has a taxonomy child)
@@ -205,15 +494,15 @@ func (g Generator) Generate(ctx *genall.GenerationContext) error {
 func (context *GeneratorContext) getFields(typ crd.TypeIdent) ([]crd.TypeIdent, bool) {
 	ListFields := []crd.TypeIdent{}
 	isTaxonomy := false
-	info, knownInfo := context.parser.Types[typ]
+	info, typeArgs, knownInfo := context.typeInfoFor(typ)
 	if knownInfo {
 		fields := info.Fields
 		for _, field := range fields {
 			fieldTypeName := field.RawField.Type
 			// Create a crd.typeIdent for the field
-			typeIdentField := typeToTypeIdent(fieldTypeName, typ.Package)
+			typeIdentField := context.typeToTypeIdent(fieldTypeName, typ.Package, typeArgs)
 
-			_, fieldKnownInfo := context.parser.Types[typeIdentField]
+			_, _, fieldKnownInfo := context.typeInfoFor(typeIdentField)
 			if !fieldKnownInfo {
 				continue
 			}
@@ -235,8 +524,13 @@ func (context *GeneratorContext) getFields(typ crd.TypeIdent) ([]crd.TypeIdent,
 	return ListFields, isTaxonomy
 }
 
-// Create a crd.TypeIdent for a given AST type
-func typeToTypeIdent(fieldTypeName ast.Expr, pkg *loader.Package) crd.TypeIdent {
+// Create a crd.TypeIdent for a given AST type, substituting type parameters with the given type arguments
+func (context *GeneratorContext) typeToTypeIdent(fieldTypeName ast.Expr, pkg *loader.Package, typeArgs map[string]typeArg) crd.TypeIdent {
+	ctx := newSchemaContext(pkg, context, context.parser.AllowDangerousTypes)
+	ctx.typeArgs = typeArgs
+	fieldTypeName, ctx = ctx.resolve(fieldTypeName)
+	pkg, typeArgs = ctx.pkg, ctx.typeArgs
+
 	typeIdentField := crd.TypeIdent{Package: nil, Name: Empty}
 	switch expr := fieldTypeName.(type) {
 	case *ast.Ident, *ast.SelectorExpr, *ast.StructType:
@@ -245,12 +539,16 @@ func typeToTypeIdent(fieldTypeName ast.Expr, pkg *loader.Package) crd.TypeIdent
 			pkgPath := loader.NonVendorPath(namedInfo.Obj().Pkg().Path())
 			typeIdentField = typeIdentFor(pkgPath, namedInfo.Obj().Name(), pkg)
 		}
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		if instanceIdent, err := instanceIdentFor(ctx, expr); err == nil {
+			typeIdentField = instanceIdent
+		}
 	case *ast.ArrayType:
-		typeIdentField = typeToTypeIdent(expr.Elt, pkg)
+		typeIdentField = context.typeToTypeIdent(expr.Elt, pkg, typeArgs)
 	case *ast.MapType:
-		typeIdentField = typeToTypeIdent(expr.Value, pkg)
+		typeIdentField = context.typeToTypeIdent(expr.Value, pkg, typeArgs)
 	case *ast.StarExpr:
-		typeIdentField = typeToTypeIdent(expr.X, pkg)
+		typeIdentField = context.typeToTypeIdent(expr.X, pkg, typeArgs)
 	}
 	return typeIdentField
 }
@@ -283,7 +581,7 @@ func indexOf(a string, list []string) int {
 
 // Remove fields that is not related to taxonomy
 func (context *GeneratorContext) removeExtraProps(typeIdent crd.TypeIdent, v *apiext.JSONSchemaProps, listFields *[]crd.TypeIdent) {
-	info, knownInfo := context.parser.Types[typeIdent]
+	info, typeArgs, knownInfo := context.typeInfoFor(typeIdent)
 	if knownInfo {
 		fieldTypes := []string{}
 		for _, typ := range *listFields {
@@ -293,13 +591,13 @@ func (context *GeneratorContext) removeExtraProps(typeIdent crd.TypeIdent, v *ap
 		for _, field := range typeFields {
 			fieldTypeName := field.RawField.Type
 			// Get the crd.TypeIdent of the current field
-			typeIdentField := typeToTypeIdent(fieldTypeName, typeIdent.Package)
+			typeIdentField := context.typeToTypeIdent(fieldTypeName, typeIdent.Package, typeArgs)
 			// If the field has a type from a package with the `schema` marker then keep it
 			if context.pkgMarkers[typeIdentField.Package].Get(schemaMarker.Name) != nil {
 				continue
 			}
 			// If the field is not in the list of the needed fields then remove it from the schema
-			_, fieldKnownInfo := context.parser.Types[typeIdentField]
+			_, _, fieldKnownInfo := context.typeInfoFor(typeIdentField)
 			if indexOf(typeIdentField.Name, fieldTypes) == -1 || !fieldKnownInfo {
 				jsonTag, hasTag := field.Tag.Lookup("json")
 				if !hasTag {
@@ -318,7 +616,7 @@ func (context *GeneratorContext) removeExtraProps(typeIdent crd.TypeIdent, v *ap
 	}
 }
 
-func (g Generator) output(documents map[string]*apiext.JSONSchemaProps) error {
+func (g Generator) output(documents map[string]document) error {
 	// create out dir if needed
 	err := os.MkdirAll(g.OutputDir, os.ModePerm)
 	if err != nil {
@@ -326,26 +624,7 @@ func (g Generator) output(documents map[string]*apiext.JSONSchemaProps) error {
 	}
 
 	for docName, doc := range documents {
-		outputFilepath := filepath.Clean(filepath.Join(g.OutputDir, docName))
-
-		// create the file
-		f, err := os.Create(outputFilepath)
-		if err != nil {
-			return err
-		}
-		//nolint:gocritic
-		defer func() {
-			if err = f.Close(); err != nil {
-				log.Printf("Error closing file: %s\n", err)
-			}
-		}()
-
-		bytes, err := json.MarshalIndent(doc, Empty, "  ")
-		if err != nil {
-			return err
-		}
-		_, err = f.Write(bytes)
-		if err != nil {
+		if err := g.writeFile(docName, doc); err != nil {
 			return err
 		}
 	}
@@ -353,6 +632,37 @@ func (g Generator) output(documents map[string]*apiext.JSONSchemaProps) error {
 	return nil
 }
 
+// writeFile writes a value to the named file of the output directory in the format of its extension
+func (g Generator) writeFile(fileName string, value interface{}) error {
+	outputFilepath := filepath.Clean(filepath.Join(g.OutputDir, fileName))
+	if err := os.MkdirAll(filepath.Dir(outputFilepath), os.ModePerm); err != nil {
+		return err
+	}
+
+	// create the file
+	f, err := os.Create(outputFilepath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err = f.Close(); err != nil {
+			log.Printf("Error closing file: %s\n", err)
+		}
+	}()
+
+	// files that keep their JSON name, like values.schema.json, are written as JSON
+	format := FormatJSON
+	if filepath.Ext(fileName) == yamlExtension {
+		format = FormatYAML
+	}
+	bytes, err := marshalValue(value, format)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(bytes)
+	return err
+}
+
 func (context *GeneratorContext) documentNameFor(pkg *loader.Package) string {
 	isManaged := context.pkgMarkers[pkg].Get(schemaMarker.Name) != nil
 	if isManaged {
@@ -383,7 +693,7 @@ func (context *GeneratorContext) TypeRefLink(from *loader.Package, to crd.TypeId
 	fromDocument := context.documentNameFor(from)
 	toDocument := context.documentNameFor(to.Package)
 
-	prefix := "#/definitions/"
+	prefix := "#/" + definitionsKeywordFor(context.generator.Dialect) + "/"
 	if fromDocument != toDocument {
 		prefix = toDocument + prefix
 	}
@@ -405,16 +715,28 @@ func (context *GeneratorContext) NeedSchemaFor(typ crd.TypeIdent) {
 		return
 	}
 
-	info, knownInfo := p.Types[typ]
+	info, typeArgs, knownInfo := context.typeInfoFor(typ)
 	if !knownInfo {
 		typ.Package.AddError(fmt.Errorf("unknown type %s", typ))
 		return
 	}
+	// generic types only have schemas for their instantiations
+	if info.RawSpec.TypeParams != nil && typeArgs == nil {
+		return
+	}
 
 	// avoid tripping recursive schemata, like ManagedFields, by adding an empty WIP schema
 	p.Schemata[typ] = apiext.JSONSchemaProps{}
 
 	schemaCtx := newSchemaContext(typ.Package, context, p.AllowDangerousTypes)
+	schemaCtx.typeArgs = typeArgs
+	schemaCtx.flattenEmbedded = context.generator.Flatten
+	schemaCtx.nullablePointers = nullablePolicy(context.generator.Nullable)
+	schemaCtx.omitDeprecated = context.generator.OmitDeprecated
+	schemaCtx.integerBounds = context.generator.IntegerBounds
+	schemaCtx.titles = context.generator.Titles
+	context.keywords[typ] = make(keywordTable)
+	schemaCtx.keywords = context.keywords[typ]
 	ctxForInfo := schemaCtx.ForInfo(info)
 
 	pkgMarkers, err := markers.PackageMarkers(p.Collector, typ.Package)
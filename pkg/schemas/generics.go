@@ -0,0 +1,202 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// typeArg is a type argument of an instantiated generic type, with the context
+// of the package (and of the type parameters) it was written in
+type typeArg struct {
+	expr ast.Expr
+	ctx  *schemaContext
+}
+
+// instance is an instantiation of a generic type
+type instance struct {
+	generic crd.TypeIdent
+	args    []typeArg
+	// key is the name of the instantiation with the packages of the type arguments
+	// qualified by their path, which tells apart arguments of packages with the same name
+	key string
+}
+
+// typeArgsFor maps the names of the type parameters of the generic type to the type arguments
+func (i instance) typeArgsFor(info *markers.TypeInfo) map[string]typeArg {
+	typeArgs := make(map[string]typeArg)
+	index := 0
+	for _, param := range info.RawSpec.TypeParams.List {
+		for _, name := range param.Names {
+			if index < len(i.args) {
+				typeArgs[name.Name] = i.args[index]
+			}
+			index++
+		}
+	}
+	return typeArgs
+}
+
+// indexed splits an instantiation expression into the generic type and its type arguments
+func indexed(expr ast.Expr) (ast.Expr, []ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.IndexExpr:
+		return expr.X, []ast.Expr{expr.Index}
+	case *ast.IndexListExpr:
+		return expr.X, expr.Indices
+	default:
+		return nil, nil
+	}
+}
+
+// genericOf returns the generic type named by the given expression
+func genericOf(ctx *schemaContext, expr ast.Expr) *types.TypeName {
+	var obj types.Object
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		obj = ctx.pkg.TypesInfo.Uses[expr]
+	case *ast.SelectorExpr:
+		obj = ctx.pkg.TypesInfo.Uses[expr.Sel]
+	}
+	typeName, _ := obj.(*types.TypeName)
+	return typeName
+}
+
+// resolve substitutes a type parameter with its type argument, returning the expression
+// together with the context it must be interpreted in
+func (c *schemaContext) resolve(expr ast.Expr) (ast.Expr, *schemaContext) {
+	for {
+		ident, isIdent := expr.(*ast.Ident)
+		if !isIdent {
+			return expr, c
+		}
+		if _, isParam := c.pkg.TypesInfo.TypeOf(ident).(*types.TypeParam); !isParam {
+			return expr, c
+		}
+		arg, known := c.typeArgs[ident.Name]
+		if !known {
+			return expr, c
+		}
+		expr, c = arg.expr, arg.ctx
+	}
+}
+
+// instanceToSchema creates a schema (ref) for an instantiated generic type. Each
+// instantiation has its own definition, named after the generic type and its type
+// arguments (e.g. List[Asset] or Pair[string,int]).
+func instanceToSchema(ctx *schemaContext, expr ast.Expr) *apiext.JSONSchemaProps {
+	typeIdent, err := instanceIdentFor(ctx, expr)
+	if err != nil {
+		ctx.pkg.AddError(loader.ErrFromNode(err, expr))
+		return &apiext.JSONSchemaProps{}
+	}
+	ctx.requestSchema(typeIdent)
+	link := ctx.schemaRequester.TypeRefLink(ctx.pkg, typeIdent)
+	return &apiext.JSONSchemaProps{
+		Ref: &link,
+	}
+}
+
+// instanceIdentFor registers an instantiation of a generic type and returns its type identifier
+func instanceIdentFor(ctx *schemaContext, expr ast.Expr) (crd.TypeIdent, error) {
+	base, indices := indexed(expr)
+	typeName := genericOf(ctx, base)
+	if typeName == nil {
+		return crd.TypeIdent{}, fmt.Errorf("unknown generic type %s", types.ExprString(expr))
+	}
+	pkgPath := loader.NonVendorPath(typeName.Pkg().Path())
+	if typeName.Pkg() == ctx.pkg.Types {
+		pkgPath = Empty
+	}
+	generic := ctx.typeIdentFor(pkgPath, typeName.Name())
+	if generic.Package == nil {
+		return generic, fmt.Errorf("package %s of generic type %s is not imported by %s", pkgPath, typeName.Name(), ctx.pkg.PkgPath)
+	}
+
+	args := make([]typeArg, 0, len(indices))
+	names := make([]string, 0, len(indices))
+	keys := make([]string, 0, len(indices))
+	for _, index := range indices {
+		argExpr, argCtx := ctx.resolve(index)
+		args = append(args, typeArg{expr: argExpr, ctx: argCtx})
+		names = append(names, typeArgName(argCtx, argExpr, typeName.Pkg(), (*types.Package).Name))
+		keys = append(keys, typeArgName(argCtx, argExpr, typeName.Pkg(), (*types.Package).Path))
+	}
+	name := typeName.Name() + "[" + strings.Join(names, ",") + "]"
+	key := typeName.Name() + "[" + strings.Join(keys, ",") + "]"
+	return ctx.schemaRequester.Instantiate(generic, name, instance{generic: generic, args: args, key: key})
+}
+
+// typeArgName returns the name of a type argument as written in Go, with the type parameters
+// substituted and the types of packages other than home qualified by the given qualifier.
+// Pointers are kept, as fields of the type parameter are nullable when it is a pointer.
+func typeArgName(ctx *schemaContext, expr ast.Expr, home *types.Package, qualifier types.Qualifier) string {
+	expr, ctx = ctx.resolve(expr)
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + typeArgName(ctx, expr.X, home, qualifier)
+	case *ast.ArrayType:
+		return "[]" + typeArgName(ctx, expr.Elt, home, qualifier)
+	case *ast.MapType:
+		return "map[" + typeArgName(ctx, expr.Key, home, qualifier) + "]" + typeArgName(ctx, expr.Value, home, qualifier)
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		base, indices := indexed(expr)
+		names := make([]string, 0, len(indices))
+		for _, index := range indices {
+			names = append(names, typeArgName(ctx, index, home, qualifier))
+		}
+		return typeArgName(ctx, base, home, qualifier) + "[" + strings.Join(names, ",") + "]"
+	default:
+		if typeName := genericOf(ctx, expr); typeName != nil {
+			if typeName.Pkg() == nil || typeName.Pkg() == home {
+				return typeName.Name()
+			}
+			return qualifier(typeName.Pkg()) + "." + typeName.Name()
+		}
+		return types.TypeString(ctx.pkg.TypesInfo.TypeOf(expr), func(pkg *types.Package) string {
+			if pkg == home {
+				return Empty
+			}
+			return qualifier(pkg)
+		})
+	}
+}
+
+// Instantiate registers an instantiation of a generic type under the given name, and returns
+// its type identifier. The first registration of a name is kept, as all have the same schema,
+// unless their type arguments are of different packages with the same name.
+func (context *GeneratorContext) Instantiate(generic crd.TypeIdent, name string, inst instance) (crd.TypeIdent, error) {
+	typeIdent := crd.TypeIdent{Package: generic.Package, Name: name}
+	known, isKnown := context.instances[typeIdent]
+	if !isKnown {
+		context.instances[typeIdent] = inst
+	} else if known.key != inst.key {
+		return typeIdent, fmt.Errorf("%s names both %s and %s, whose type arguments are of different packages with the same name",
+			name, known.key, inst.key)
+	}
+	return typeIdent, nil
+}
+
+// typeInfoFor returns the type information of a type, which for an instantiation is
+// that of its generic type, together with the type arguments of the instantiation
+func (context *GeneratorContext) typeInfoFor(typ crd.TypeIdent) (*markers.TypeInfo, map[string]typeArg, bool) {
+	inst, isInstance := context.instances[typ]
+	if !isInstance {
+		info, knownInfo := context.parser.Types[typ]
+		return info, nil, knownInfo
+	}
+	info, knownInfo := context.parser.Types[inst.generic]
+	if !knownInfo {
+		return nil, nil, false
+	}
+	return info, inst.typeArgsFor(info), true
+}
@@ -0,0 +1,98 @@
+package schemas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+)
+
+func TestGenerics(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/generics")
+	doc := documents["generics.json"]
+	definitions := doc[definitionsKeyword].(map[string]interface{})
+	for _, name := range []string{"List", "Pair", "Index"} {
+		if _, exists := definitions[name]; exists {
+			t.Errorf("unexpected definition of generic type %s", name)
+		}
+	}
+	for _, name := range []string{"List[Asset]", "Pair[string,int]", "Index[Asset]", "List[Pair[string,Asset]]",
+		"Pair[string,Asset]", "List[string]", "List[schemapkg.SchemaType1]"} {
+		if _, exists := definitions[name]; !exists {
+			t.Errorf("missing definition %s", name)
+		}
+	}
+
+	catalog := definitions["Catalog"].(map[string]interface{})["properties"].(map[string]interface{})
+	if ref := catalog["pointers"].(map[string]interface{})["$ref"]; ref != "#/definitions/List[*Asset]" {
+		t.Errorf("unexpected reference %v to a list of pointers", ref)
+	}
+
+	// the definitions referencing other documents can't be resolved here
+	local := make(map[string]interface{})
+	for _, name := range []string{"Index[Asset]", "List[Pair[string,Asset]]", "Pair[string,Asset]", "Asset"} {
+		local[name] = definitions[name]
+	}
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Index[Asset]",
+		definitionsKeyword: local,
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{
+			"entries": map[string]interface{}{"a": map[string]interface{}{"name": "a"}},
+			"pages": map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"key": "a", "value": map[string]interface{}{"name": "a"}},
+			}},
+		}, true},
+		{map[string]interface{}{
+			"entries": map[string]interface{}{"a": "a"},
+			"pages":   map[string]interface{}{"items": []interface{}{}},
+		}, false},
+		{map[string]interface{}{
+			"entries": map[string]interface{}{},
+			"pages":   map[string]interface{}{"items": []interface{}{map[string]interface{}{"key": 1, "value": map[string]interface{}{"name": "a"}}}},
+		}, false},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v", test.instance, test.valid)
+		}
+	}
+}
+
+func TestGenericsPointerArguments(t *testing.T) {
+	documents := generate(t, Generator{Nullable: NullableType}, "../../testPkgs/generics")
+	definitions := documents["generics.json"][definitionsKeyword].(map[string]interface{})
+	for name, nullable := range map[string]bool{"Pair[string,*Asset]": true, "Pair[string,Asset]": false} {
+		value := definitions[name].(map[string]interface{})["properties"].(map[string]interface{})["value"].(map[string]interface{})
+		if _, ok := value["anyOf"]; ok != nullable {
+			t.Errorf("expected nullable %v for the value of %s: %v", nullable, name, value)
+		}
+	}
+}
+
+func TestGenericsNameCollision(t *testing.T) {
+	var generator genall.Generator = &Generator{OutputDir: t.TempDir()}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/collision")
+	if err != nil {
+		t.Fatalf("could not load roots: %v", err)
+	}
+	if !runtime.Run() {
+		t.Fatal("expected an error for instantiations with the same name")
+	}
+	var messages []string
+	for _, err := range runtime.Roots[0].Errors {
+		messages = append(messages, err.Error())
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "lists.go:19") ||
+		!strings.Contains(messages[0], "List[v1.Foo] names both") {
+		t.Errorf("unexpected errors %v", messages)
+	}
+}
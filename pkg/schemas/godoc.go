@@ -0,0 +1,225 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"go/ast"
+	"go/doc/comment"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// docLinkPattern matches the Markdown links of descriptions that point into a document,
+// like the links of doc links, capturing their text and their reference
+var docLinkPattern = regexp.MustCompile(`\[((?:[^\]\\]|\\.)*)\]\(([^()\s]*#[^()\s]*)\)`)
+
+// describingKeywords are the keywords whose text may have doc links
+var describingKeywords = []string{"description", deprecatedMessageKeyword}
+
+// isMarkerComment tells if a comment is a marker rather than documentation
+func isMarkerComment(text string) bool {
+	return strings.HasPrefix(text, "//") && strings.HasPrefix(strings.TrimSpace(text[2:]), "+")
+}
+
+// docText returns the text of a doc comment without its markers
+func docText(group *ast.CommentGroup) string {
+	if group == nil {
+		return Empty
+	}
+	var doc ast.CommentGroup
+	for _, comment := range group.List {
+		if !isMarkerComment(comment.Text) {
+			doc.List = append(doc.List, comment)
+		}
+	}
+	if len(doc.List) == 0 {
+		return Empty
+	}
+	return doc.Text()
+}
+
+// typeDoc returns the doc comment of a type, which is attached to its declaration
+// when it is declared alone
+func typeDoc(info *markers.TypeInfo) *ast.CommentGroup {
+	if info.RawSpec == nil {
+		return nil
+	}
+	if info.RawSpec.Doc == nil && info.RawDecl != nil && info.RawDecl.Lparen == token.NoPos {
+		return info.RawDecl.Doc
+	}
+	return info.RawSpec.Doc
+}
+
+// packageDoc returns the text of the package doc comments of a package
+func packageDoc(pkg *loader.Package) string {
+	var texts []string
+	for _, file := range pkg.Syntax {
+		if text := docText(file.Doc); text != Empty {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// parseDoc parses the text of a doc comment, resolving its doc links to the types of the
+// package and of the packages it imports
+func (c *schemaContext) parseDoc(text string) *comment.Doc {
+	parser := &comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			for _, imported := range c.pkg.Types.Imports() {
+				if imported.Name() == name {
+					return imported.Path(), true
+				}
+			}
+			return Empty, false
+		},
+		LookupSym: func(recv, name string) bool {
+			_, isType := c.pkg.Types.Scope().Lookup(name).(*types.TypeName)
+			return recv == Empty && isType
+		},
+	}
+	return parser.Parse(text)
+}
+
+// markdown prints a doc comment as CommonMark. Doc links to types are links to their
+// definitions, with the reference a schema of the package would have to them, and
+// headings have no ID, which is not part of CommonMark.
+func (c *schemaContext) markdown(doc *comment.Doc) string {
+	printer := &comment.Printer{
+		DocLinkURL: c.docLinkURL,
+		HeadingID:  func(*comment.Heading) string { return Empty },
+	}
+	return strings.TrimRight(string(printer.Markdown(doc)), "\n")
+}
+
+// docLinkURL returns the reference to the definition of the type of a doc link. Links to
+// methods, or to names that are not types, are left as text.
+func (c *schemaContext) docLinkURL(link *comment.DocLink) string {
+	if link.Recv != Empty {
+		return Empty
+	}
+	scope := c.pkg.Types.Scope()
+	pkgPath := Empty
+	if link.ImportPath != Empty && link.ImportPath != c.pkg.Types.Path() {
+		scope = nil
+		for _, imported := range c.pkg.Types.Imports() {
+			if imported.Path() == link.ImportPath {
+				scope = imported.Scope()
+				pkgPath = loader.NonVendorPath(link.ImportPath)
+			}
+		}
+		if scope == nil {
+			return Empty
+		}
+	}
+	if _, isType := scope.Lookup(link.Name).(*types.TypeName); !isType {
+		return Empty
+	}
+	typeIdent := c.typeIdentFor(pkgPath, link.Name)
+	if typeIdent.Package == nil {
+		return Empty
+	}
+	return c.schemaRequester.TypeRefLink(c.pkg, typeIdent)
+}
+
+// firstSentence returns the first sentence of the first paragraph of a doc comment, as plain text
+func firstSentence(doc *comment.Doc) string {
+	if len(doc.Content) == 0 {
+		return Empty
+	}
+	paragraph, isParagraph := doc.Content[0].(*comment.Paragraph)
+	if !isParagraph {
+		return Empty
+	}
+	text := strings.Join(strings.Fields(plainText(paragraph.Text)), " ")
+	if end := strings.Index(text, ". "); end >= 0 {
+		text = text[:end]
+	}
+	return strings.TrimSuffix(text, ".")
+}
+
+// plainText returns the text of a doc comment text sequence, without its markup
+func plainText(texts []comment.Text) string {
+	var out strings.Builder
+	for _, text := range texts {
+		switch text := text.(type) {
+		case comment.Plain:
+			out.WriteString(string(text))
+		case comment.Italic:
+			out.WriteString(string(text))
+		case *comment.Link:
+			out.WriteString(plainText(text.Text))
+		case *comment.DocLink:
+			out.WriteString(plainText(text.Text))
+		}
+	}
+	return out.String()
+}
+
+// replaceDocLinks replaces the links of the descriptions of a schema that point into a
+// document with the result of fn for their text and their reference
+func replaceDocLinks(schema map[string]interface{}, fn func(text, ref string) string) {
+	for _, keyword := range describingKeywords {
+		description, ok := schema[keyword].(string)
+		if !ok {
+			continue
+		}
+		schema[keyword] = docLinkPattern.ReplaceAllStringFunc(description, func(link string) string {
+			groups := docLinkPattern.FindStringSubmatch(link)
+			return fn(groups[1], groups[2])
+		})
+	}
+}
+
+// unlinkUnresolved leaves the text of the links of descriptions that do not resolve in
+// the documents, such as doc links to types that have no definition
+func unlinkUnresolved(docs map[string]document) {
+	for docName, doc := range docs {
+		walkSchemas(doc, func(schema map[string]interface{}) {
+			replaceDocLinks(schema, func(text, ref string) string {
+				if resolves(docs, docName, ref) {
+					return "[" + text + "](" + ref + ")"
+				}
+				return text
+			})
+		})
+	}
+}
+
+// resolves tells if a reference points at a value of the documents. References to
+// definitions resolve under either definitions keyword, as the dialect may rename it.
+func resolves(docs map[string]document, fromDocument, ref string) bool {
+	if docName, definitionName, ok := parseDefinitionRef(ref, fromDocument); ok {
+		_, found := definitionsOf(docs[docName])[definitionName]
+		return found
+	}
+	docName, pointer, found := strings.Cut(ref, "#")
+	if !found {
+		return false
+	}
+	if docName == Empty {
+		docName = fromDocument
+	}
+	doc, found := docs[docName]
+	if !found {
+		return false
+	}
+	var current interface{} = map[string]interface{}(doc)
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		object, isObject := current.(map[string]interface{})
+		if !isObject {
+			return false
+		}
+		if current, found = object[unescape.Replace(token)]; !found {
+			return false
+		}
+	}
+	return true
+}
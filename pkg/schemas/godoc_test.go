@@ -0,0 +1,61 @@
+package schemas
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGodocDescriptions(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/godoc", "../../testPkgs/embedded")
+	doc := documents["godoc.json"]
+	if doc["description"] != "Package godoc has doc comments in the Go doc syntax.\n\nIts descriptions are in CommonMark." {
+		t.Errorf("unexpected document description %q", doc["description"])
+	}
+
+	definitions := doc[definitionsKeyword].(map[string]interface{})
+	catalog := definitions["Catalog"].(map[string]interface{})
+	description := catalog["description"].(string)
+	for _, expected := range []string{
+		"Catalog lists [Asset](#/definitions/Asset) items.",
+		"\n\n### Usage\n\n",
+		"\n\n\tcatalog.Assets[\"name\"]\n\n",
+		"\n  - assets, see [Asset](#/definitions/Asset)\n",
+		"\n  - a status, see [embedded.Status](embedded.json#/definitions/Status)\n",
+	} {
+		if !strings.Contains(description, expected) {
+			t.Errorf("expected %q in description %q", expected, description)
+		}
+	}
+	if _, ok := catalog["title"]; ok {
+		t.Errorf("unexpected title without the option: %v", catalog["title"])
+	}
+
+	properties := catalog["properties"].(map[string]interface{})
+	status := properties["status"].(map[string]interface{})
+	if status["description"] != "Status of the catalog" ||
+		status[deprecatedMessageKeyword] != "see [embedded.Status](embedded.json#/definitions/Status) instead." {
+		t.Errorf("unexpected status %v", status)
+	}
+	// io.Reader has no definition, so the doc link is left as text
+	owner := properties["owner"].(map[string]interface{})
+	if owner["description"] != "Owner of the catalog, not an io.Reader" {
+		t.Errorf("unexpected owner description %q", owner["description"])
+	}
+}
+
+func TestGodocTitles(t *testing.T) {
+	documents := generate(t, Generator{Titles: true, Dialect: Dialect202012, Format: FormatYAML},
+		"../../testPkgs/godoc", "../../testPkgs/embedded")
+	definitions := documents["godoc.yaml"][defsKeyword].(map[string]interface{})
+	catalog := definitions["Catalog"].(map[string]interface{})
+	if catalog["title"] != "Catalog lists Asset items" {
+		t.Errorf("unexpected title %q", catalog["title"])
+	}
+	if !strings.Contains(catalog["description"].(string), "[embedded.Status](embedded.yaml#/$defs/Status)") {
+		t.Errorf("expected the doc link to the renamed document in %q", catalog["description"])
+	}
+	assets := catalog["properties"].(map[string]interface{})["assets"].(map[string]interface{})
+	if assets["title"] != "Assets by name, each an Asset" {
+		t.Errorf("unexpected title %q", assets["title"])
+	}
+}
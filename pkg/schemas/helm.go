@@ -0,0 +1,118 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	helmSchemaFileName = "values.schema.json"
+	// helmGlobalProperty holds the values Helm shares between a chart and its subcharts
+	helmGlobalProperty = "global"
+)
+
+// unescapePointer decodes a JSON pointer token
+var unescapePointer = strings.NewReplacer("~1", "/", "~0", "~")
+
+// ChartPath is the path of the chart a values schema is written to, relative to the output directory
+type ChartPath string
+
+// helmSchemas returns the values.schema.json document of each type with helm marker, by
+// its path. It is a bundled draft-07 document, the dialect Helm validates values with.
+func (context *GeneratorContext) helmSchemas(documents map[string]document) (map[string]interface{}, error) {
+	schemas := make(map[string]interface{})
+	for chartPath, typeIdent := range context.helmCharts {
+		fileName := path.Join(chartPath, helmSchemaFileName)
+		docName := context.documentNameFor(typeIdent.Package)
+		definitionName := context.definitionNameFor(docName, typeIdent)
+		schema, found := definitionsOf(documents[docName])[definitionName].(map[string]interface{})
+		if !found {
+			return nil, fmt.Errorf("no schema for the values of %s: %s", chartPath, typeIdent)
+		}
+
+		// the root references definitions relative to the document it comes from
+		root := deepCopyJSON(schema).(map[string]interface{})
+		rewriteRefs(root, func(ref string) string {
+			if strings.HasPrefix(ref, "#") {
+				return docName + ref
+			}
+			return ref
+		})
+		withRoot := make(map[string]document, len(documents)+1)
+		for name, doc := range documents {
+			withRoot[name] = doc
+		}
+		withRoot[fileName] = document(root)
+		doc, err := context.bundle(fileName, withRoot)
+		if err != nil {
+			return nil, err
+		}
+
+		shortenDefinitionNames(doc, fileName)
+		allowGlobalValues(doc)
+		toJSONSchemaKeywords(doc)
+		toDraft07(fileName, doc)
+		doc["$schema"] = metaSchemas[DialectDraft07]
+		schemas[fileName] = doc
+	}
+	return schemas, nil
+}
+
+// shortenDefinitionNames names the definitions of a bundled document after their type only,
+// unless another definition has the same name, and points every reference at the
+// definitions keyword of draft-07
+func shortenDefinitionNames(doc document, docName string) {
+	definitions := definitionsOf(doc)
+	delete(doc, defsKeyword)
+	if len(definitions) == 0 {
+		delete(doc, definitionsKeyword)
+		return
+	}
+
+	names := make(map[string]string, len(definitions))
+	taken := make(map[string]bool, len(definitions))
+	for _, name := range sortedKeys(definitions) {
+		if !strings.Contains(name, "~0") {
+			taken[name] = true
+		}
+	}
+	shortened := make(map[string]interface{}, len(definitions))
+	for _, name := range sortedKeys(definitions) {
+		short := name
+		if index := strings.LastIndex(name, "~0"); index != -1 && !taken[name[index+2:]] {
+			short = name[index+2:]
+		}
+		taken[short] = true
+		names[name] = short
+		// references are JSON pointers, in which the definition name is escaped
+		shortened[unescapePointer.Replace(short)] = definitions[name]
+	}
+	doc[definitionsKeyword] = shortened
+	rewriteRefs(doc, func(ref string) string {
+		if _, definitionName, ok := parseDefinitionRef(ref, docName); ok {
+			return "#/" + definitionsKeyword + "/" + names[definitionName]
+		}
+		return ref
+	})
+}
+
+// allowGlobalValues adds the global values, that Helm passes to every chart, to the
+// properties of the root unless it describes them already
+func allowGlobalValues(doc document) {
+	properties, ok := doc["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if _, defined := properties[helmGlobalProperty]; defined {
+		return
+	}
+	properties[helmGlobalProperty] = map[string]interface{}{
+		"type":                 "object",
+		"description":          "Global values shared between the chart and its subcharts",
+		"additionalProperties": true,
+	}
+}
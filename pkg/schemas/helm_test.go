@@ -0,0 +1,50 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestHelmSchema(t *testing.T) {
+	documents := generate(t, Generator{Format: FormatYAML}, "../../testPkgs/helmvalues")
+	doc, ok := documents["charts/sample/values.schema.json"]
+	if !ok {
+		t.Fatal("values.schema.json is missing")
+	}
+	if doc["$schema"] != "http://json-schema.org/draft-07/schema#" {
+		t.Errorf("unexpected $schema %v", doc["$schema"])
+	}
+	properties := doc["properties"].(map[string]interface{})
+	if properties["image"].(map[string]interface{})["$ref"] != "#/definitions/Image" {
+		t.Errorf("unexpected reference %v", properties["image"])
+	}
+	if properties["replicaCount"].(map[string]interface{})["description"] != "Number of replicas of the deployment" {
+		t.Errorf("unexpected description %v", properties["replicaCount"])
+	}
+	definitions := doc[definitionsKeyword].(map[string]interface{})
+	if _, ok := definitions["SchemaType1"]; !ok {
+		t.Errorf("taxonomy definition is not bundled: %v", sortedKeys(definitions))
+	}
+
+	values := map[string]interface{}{
+		"replicaCount": 2,
+		"image":        map[string]interface{}{"repository": "nginx", "pullPolicy": "Always"},
+		"global":       map[string]interface{}{"registry": "example.com"},
+	}
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(doc), gojsonschema.NewGoLoader(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid() {
+		t.Errorf("values should be valid: %v", result.Errors())
+	}
+	values["replicaCount"] = 0
+	result, err = gojsonschema.Validate(gojsonschema.NewGoLoader(doc), gojsonschema.NewGoLoader(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid() {
+		t.Error("values below the minimum should not be valid")
+	}
+}
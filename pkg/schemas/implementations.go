@@ -0,0 +1,205 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	implementationsMarkerName         = "fybrik:validation:implementations"
+	discoverImplementationsMarkerName = "fybrik:validation:discoverImplementations"
+)
+
+// Implementations lists the types implementing an interface, by name for types of the
+// package of the marker or qualified by their import path for others (path/to/pkg.Name)
+type Implementations []string
+
+// implementationsMarkers are the markers listing or discovering the implementations
+// of an interface, on the interface type or on a field of an interface type
+var implementationsMarkers = []*markers.Definition{
+	markers.Must(markers.MakeDefinition(implementationsMarkerName, markers.DescribesType, Implementations{})),
+	markers.Must(markers.MakeDefinition(implementationsMarkerName, markers.DescribesField, Implementations{})),
+	markers.Must(markers.MakeDefinition(discoverImplementationsMarkerName, markers.DescribesType, struct{}{})),
+	markers.Must(markers.MakeDefinition(discoverImplementationsMarkerName, markers.DescribesField, struct{}{})),
+}
+
+// hasImplementationsMarker tells if the markers list or discover the implementations of an interface
+func hasImplementationsMarker(markerSet markers.MarkerValues) bool {
+	return markerSet.Get(implementationsMarkerName) != nil || markerSet.Get(discoverImplementationsMarkerName) != nil
+}
+
+// implementationsToSchema creates a schema accepting any of the implementations of the interface
+// type of the given node, as a oneOf of references to them. The implementations are listed or
+// discovered by the given markers. Implementations that oneOf can not tell apart are an error.
+func implementationsToSchema(ctx *schemaContext, markerSet markers.MarkerValues, node ast.Expr) *apiext.JSONSchemaProps {
+	typ := ctx.pkg.TypesInfo.TypeOf(node)
+	if pointer, isPointer := typ.(*types.Pointer); isPointer {
+		typ = pointer.Elem()
+	}
+	iface, isInterface := typ.Underlying().(*types.Interface)
+	if !isInterface {
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("implementations can only be given for interfaces, not %s", typ), node))
+		return &apiext.JSONSchemaProps{}
+	}
+
+	var implementations []crd.TypeIdent
+	if names, listed := markerSet.Get(implementationsMarkerName).(Implementations); listed {
+		for _, name := range names {
+			typeIdent, err := implementationFor(ctx, name, iface)
+			if err != nil {
+				ctx.pkg.AddError(loader.ErrFromNode(err, node))
+				continue
+			}
+			implementations = append(implementations, typeIdent)
+		}
+	}
+	if markerSet.Get(discoverImplementationsMarkerName) != nil {
+		implementations = append(implementations, ctx.schemaRequester.Implementations(iface)...)
+	}
+	if len(implementations) == 0 {
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("no implementations of %s", typ), node))
+		return &apiext.JSONSchemaProps{}
+	}
+
+	props := &apiext.JSONSchemaProps{}
+	for _, typeIdent := range implementations {
+		ctx.requestSchema(typeIdent)
+		link := ctx.schemaRequester.TypeRefLink(ctx.pkg, typeIdent)
+		props.OneOf = append(props.OneOf, apiext.JSONSchemaProps{Ref: &link})
+	}
+	if err := checkDistinct(ctx, implementations); err != nil {
+		ctx.pkg.AddError(loader.ErrFromNode(err, node))
+	}
+	return props
+}
+
+// checkDistinct checks that oneOf can tell the implementations apart: no value of an
+// implementation may also be a value of another. Implementations whose schema is still
+// being generated are not checked.
+func checkDistinct(ctx *schemaContext, implementations []crd.TypeIdent) error {
+	for i, typeIdent := range implementations {
+		schema, known := ctx.schemaRequester.SchemaFor(typeIdent)
+		if !known {
+			continue
+		}
+		for _, other := range implementations[i+1:] {
+			otherSchema, otherKnown := ctx.schemaRequester.SchemaFor(other)
+			if otherKnown && (includes(&schema, &otherSchema) || includes(&otherSchema, &schema)) {
+				return fmt.Errorf("implementations %s and %s can not be told apart",
+					implementationName(ctx, typeIdent), implementationName(ctx, other))
+			}
+		}
+	}
+	return nil
+}
+
+// implementationName names an implementation as implementations markers do
+func implementationName(ctx *schemaContext, typeIdent crd.TypeIdent) string {
+	if typeIdent.Package == ctx.pkg {
+		return typeIdent.Name
+	}
+	return typeIdent.Package.PkgPath + "." + typeIdent.Name
+}
+
+// includes tells if every value of the other schema is also a value of the schema, as far as
+// their shape tells: objects are included when the schema requires no property that the other
+// does not require, and the properties they both have are the same. Descriptions are ignored.
+func includes(schema, other *apiext.JSONSchemaProps) bool {
+	if schema.Type != "object" || other.Type != "object" {
+		return reflect.DeepEqual(withoutDescriptions(schema), withoutDescriptions(other))
+	}
+	for _, name := range schema.Required {
+		if indexOf(name, other.Required) == -1 {
+			return false
+		}
+	}
+	for name := range schema.Properties {
+		property, otherProperty := schema.Properties[name], other.Properties[name]
+		if _, found := other.Properties[name]; found &&
+			!reflect.DeepEqual(withoutDescriptions(&property), withoutDescriptions(&otherProperty)) {
+			return false
+		}
+	}
+	return len(schema.AllOf) == 0 && len(other.AllOf) == 0
+}
+
+// withoutDescriptions returns a copy of a schema without its title and descriptions
+func withoutDescriptions(schema *apiext.JSONSchemaProps) *apiext.JSONSchemaProps {
+	stripped := schema.DeepCopy()
+	stripped.Title = Empty
+	stripped.Description = Empty
+	for name := range stripped.Properties {
+		property := stripped.Properties[name]
+		stripped.Properties[name] = *withoutDescriptions(&property)
+	}
+	return stripped
+}
+
+// implementationFor resolves a type listed in an implementations marker, checking that
+// the type, or a pointer to it, implements the interface
+func implementationFor(ctx *schemaContext, name string, iface *types.Interface) (crd.TypeIdent, error) {
+	pkgPath, typeName := Empty, name
+	if index := strings.LastIndex(name, "."); index != -1 {
+		pkgPath, typeName = name[:index], name[index+1:]
+	}
+	typeIdent := ctx.typeIdentFor(pkgPath, typeName)
+	if typeIdent.Package == nil {
+		return typeIdent, fmt.Errorf("package %s of implementation %s is not imported by %s", pkgPath, name, ctx.pkg.PkgPath)
+	}
+	typeIdent.Package.NeedTypesInfo()
+	obj, isTypeName := typeIdent.Package.Types.Scope().Lookup(typeName).(*types.TypeName)
+	if !isTypeName {
+		return typeIdent, fmt.Errorf("unknown implementation %s", name)
+	}
+	if !implements(obj.Type(), iface) {
+		return typeIdent, fmt.Errorf("%s does not implement the interface", name)
+	}
+	return typeIdent, nil
+}
+
+// implements tells if a concrete type, or a pointer to it, implements an interface
+func implements(typ types.Type, iface *types.Interface) bool {
+	if types.IsInterface(typ) {
+		return false
+	}
+	return types.Implements(typ, iface) || types.Implements(types.NewPointer(typ), iface)
+}
+
+// isGeneric tells if a type is a generic type that is not instantiated
+func isGeneric(typ types.Type) bool {
+	named, isNamed := typ.(*types.Named)
+	return isNamed && named.TypeParams().Len() > 0 && named.TypeArgs().Len() == 0
+}
+
+// Implementations returns the types of the loaded packages that implement an interface,
+// ordered by package path and name
+func (context *GeneratorContext) Implementations(iface *types.Interface) []crd.TypeIdent {
+	var implementations []crd.TypeIdent
+	for typeIdent := range context.parser.Types {
+		typeIdent.Package.NeedTypesInfo()
+		obj, isTypeName := typeIdent.Package.Types.Scope().Lookup(typeIdent.Name).(*types.TypeName)
+		// generic types only implement interfaces once instantiated
+		if isTypeName && !isGeneric(obj.Type()) && implements(obj.Type(), iface) {
+			implementations = append(implementations, typeIdent)
+		}
+	}
+	sort.Slice(implementations, func(i, j int) bool {
+		if implementations[i].Package.PkgPath != implementations[j].Package.PkgPath {
+			return implementations[i].Package.PkgPath < implementations[j].Package.PkgPath
+		}
+		return implementations[i].Name < implementations[j].Name
+	})
+	return implementations
+}
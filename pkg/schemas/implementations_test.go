@@ -0,0 +1,89 @@
+package schemas
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+)
+
+func TestImplementations(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/plugins")
+	definitions := documents["plugins.json"][definitionsKeyword].(map[string]interface{})
+
+	oneOfRefs := func(schema interface{}) []string {
+		var refs []string
+		for _, branch := range schema.(map[string]interface{})["oneOf"].([]interface{}) {
+			refs = append(refs, branch.(map[string]interface{})["$ref"].(string))
+		}
+		return refs
+	}
+	if refs := oneOfRefs(definitions["Action"]); !reflect.DeepEqual(refs,
+		[]string{"#/definitions/RedactAction", "#/definitions/RemoveAction"}) {
+		t.Errorf("unexpected listed implementations %v", refs)
+	}
+	if refs := oneOfRefs(definitions["Transform"]); !reflect.DeepEqual(refs,
+		[]string{"#/definitions/HashTransform", "#/definitions/RedactAction"}) {
+		t.Errorf("unexpected discovered implementations %v", refs)
+	}
+
+	properties := definitions["Policy"].(map[string]interface{})["properties"].(map[string]interface{})
+	if properties["action"].(map[string]interface{})["$ref"] != "#/definitions/Action" {
+		t.Errorf("unexpected interface field %v", properties["action"])
+	}
+	fallback := properties["fallback"]
+	if refs := oneOfRefs(fallback); !reflect.DeepEqual(refs, []string{"#/definitions/RemoveAction"}) {
+		t.Errorf("unexpected field implementations %v", refs)
+	}
+	if fallback.(map[string]interface{})["description"] == nil {
+		t.Error("field description is missing")
+	}
+}
+
+func TestImplementationsValidate(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/plugins")
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Policy",
+		definitionsKeyword: documents["plugins.json"][definitionsKeyword],
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"action": map[string]interface{}{"redact": []interface{}{"a"}}}, true},
+		{map[string]interface{}{"action": map[string]interface{}{}}, false},
+		// an action can only be one of the implementations
+		{map[string]interface{}{"action": map[string]interface{}{"redact": []interface{}{"a"}, "remove": []interface{}{"b"}}}, false},
+		{map[string]interface{}{"action": map[string]interface{}{"redact": []interface{}{"a"}},
+			"transforms": []interface{}{map[string]interface{}{"algorithm": "sha256"}}}, true},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v, got %v", test.instance, test.valid, result.Errors())
+		}
+	}
+}
+
+func TestImplementationsNotDistinct(t *testing.T) {
+	var generator genall.Generator = &Generator{OutputDir: t.TempDir()}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/ambiguous")
+	if err != nil {
+		t.Fatalf("could not load roots: %v", err)
+	}
+	if !runtime.Run() {
+		t.Fatal("expected an error for implementations that can not be told apart")
+	}
+	var messages []string
+	for _, err := range runtime.Roots[0].Errors {
+		messages = append(messages, err.Error())
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "actions.go:8") ||
+		!strings.Contains(messages[0], "implementations RedactAction and RemoveAction can not be told apart") {
+		t.Errorf("unexpected errors %v", messages)
+	}
+}
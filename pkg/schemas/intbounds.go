@@ -0,0 +1,102 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"math"
+	"strconv"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+const (
+	minimumKeyword = "minimum"
+	maximumKeyword = "maximum"
+)
+
+// integerKindBounds are the minimum and maximum values of each integer kind. int and uint
+// are bounded as int64 and uint64, their size on the platforms encoding/json mostly runs on.
+var integerKindBounds = map[types.BasicKind][2]json.Number{
+	types.Int8:    kindBounds(math.MinInt8, math.MaxInt8),
+	types.Int16:   kindBounds(math.MinInt16, math.MaxInt16),
+	types.Int32:   kindBounds(math.MinInt32, math.MaxInt32),
+	types.Int64:   kindBounds(math.MinInt64, math.MaxInt64),
+	types.Int:     kindBounds(math.MinInt64, math.MaxInt64),
+	types.Uint8:   kindBounds(0, math.MaxUint8),
+	types.Uint16:  kindBounds(0, math.MaxUint16),
+	types.Uint32:  kindBounds(0, math.MaxUint32),
+	types.Uint64:  kindBounds(0, math.MaxUint64),
+	types.Uint:    kindBounds(0, math.MaxUint64),
+	types.Uintptr: kindBounds(0, math.MaxUint64),
+}
+
+// formatMaximums are the maximum values of the integer formats
+var formatMaximums = map[string]float64{
+	"int32": math.MaxInt32,
+	"int64": math.MaxInt64,
+}
+
+// kindBounds returns the minimum and maximum of an integer kind as JSON numbers
+func kindBounds(minimum int64, maximum uint64) [2]json.Number {
+	return [2]json.Number{json.Number(strconv.FormatInt(minimum, 10)), json.Number(strconv.FormatUint(maximum, 10))}
+}
+
+// setIntegerBounds sets the minimum and maximum of a schema to the bounds of an integer kind.
+// The bounds of the 64 bits kinds are rounded as float64, out of the range of the kind, so
+// their exact values are written to the document instead. The format of unsigned kinds, whose
+// maximum is out of the range of the signed format, is dropped.
+func setIntegerBounds(ctx *schemaContext, props *apiext.JSONSchemaProps, kind types.BasicKind) {
+	bounds, isInteger := integerKindBounds[kind]
+	if !isInteger {
+		return
+	}
+	minimum, _ := bounds[0].Float64()
+	maximum, _ := bounds[1].Float64()
+	props.Minimum = &minimum
+	props.Maximum = &maximum
+	if formatMaximum, hasFormat := formatMaximums[props.Format]; hasFormat && maximum > formatMaximum {
+		props.Format = Empty
+	}
+	ctx.setKeyword(minimumKeyword, bounds[0])
+	ctx.setKeyword(maximumKeyword, bounds[1])
+}
+
+// keepTighterBounds restores the minimum and maximum a schema had before markers were
+// applied, where the markers set looser ones. A restored bound is inclusive. The exact
+// bounds of the kind are dropped where the markers set tighter ones. Bounds that leave
+// no value are an error at the given node.
+func keepTighterBounds(ctx *schemaContext, props *apiext.JSONSchemaProps, minimum, maximum *float64, node ast.Node) {
+	if minimum == nil && maximum == nil {
+		return
+	}
+	if minimum != nil && (props.Minimum == nil || *props.Minimum < *minimum) {
+		props.Minimum = minimum
+		props.ExclusiveMinimum = false
+	} else if minimum != nil && props.Minimum != minimum {
+		delete(ctx.schemaKeywords(), minimumKeyword)
+	}
+	if maximum != nil && (props.Maximum == nil || *props.Maximum > *maximum) {
+		props.Maximum = maximum
+		props.ExclusiveMaximum = false
+	} else if maximum != nil && props.Maximum != maximum {
+		delete(ctx.schemaKeywords(), maximumKeyword)
+	}
+	if !acceptsValues(props) {
+		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("minimum %v and maximum %v accept no value",
+			*props.Minimum, *props.Maximum), node))
+	}
+}
+
+// acceptsValues tells if some numbers are within the minimum and maximum of a schema
+func acceptsValues(props *apiext.JSONSchemaProps) bool {
+	if props.Minimum == nil || props.Maximum == nil || *props.Minimum < *props.Maximum {
+		return true
+	}
+	return *props.Minimum == *props.Maximum && !props.ExclusiveMinimum && !props.ExclusiveMaximum
+}
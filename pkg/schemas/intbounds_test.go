@@ -0,0 +1,113 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+)
+
+func TestIntegerBounds(t *testing.T) {
+	documents := generate(t, Generator{IntegerBounds: true}, "../../testPkgs/validations")
+	definitions := documents["validations.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Sizes"].(map[string]interface{})["properties"].(map[string]interface{})
+	for name, bounds := range map[string][2]string{
+		"small":  {"-128", "127"},
+		"medium": {"-2147483648", "2147483647"},
+		"count":  {"0", "18446744073709551615"},
+		"large":  {"0", "18446744073709551615"},
+		"ratio":  {"-9223372036854775808", "9223372036854775807"},
+		"total":  {"0", "9223372036854775807"},
+		"octet":  {"0", "255"},
+		"char":   {"-2147483648", "2147483647"},
+		"offset": {"-1000", "100"},
+	} {
+		property := properties[name].(map[string]interface{})
+		if fmt.Sprint(property["minimum"]) != bounds[0] || fmt.Sprint(property["maximum"]) != bounds[1] {
+			t.Errorf("unexpected bounds of %s: %v", name, property)
+		}
+	}
+	if properties["offset"].(map[string]interface{})["exclusiveMaximum"] != true {
+		t.Errorf("expected the exclusive maximum of the marker to be kept")
+	}
+
+	// the signed formats can not hold the maximum of the unsigned kinds
+	for name, format := range map[string]interface{}{"medium": "int32", "ratio": nil, "large": nil, "total": "int64"} {
+		if property := properties[name].(map[string]interface{}); property["format"] != format {
+			t.Errorf("unexpected format of %s: %v", name, property)
+		}
+	}
+
+	// the maximum marker of Port is looser than uint16, its minimum marker is tighter
+	port := definitions["Port"].(map[string]interface{})
+	if fmt.Sprint(port["minimum"]) != "1" || fmt.Sprint(port["maximum"]) != "65535" {
+		t.Errorf("unexpected bounds of Port: %v", port)
+	}
+}
+
+func TestIntegerBoundsUnsatisfiable(t *testing.T) {
+	var generator genall.Generator = &Generator{OutputDir: t.TempDir(), IntegerBounds: true}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/unsatisfiable")
+	if err != nil {
+		t.Fatalf("could not load roots: %v", err)
+	}
+	if !runtime.Run() {
+		t.Fatal("expected an error for bounds that accept no value")
+	}
+	var messages []string
+	for _, err := range runtime.Roots[0].Errors {
+		messages = append(messages, err.Error())
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "bounds.go:9") ||
+		!strings.Contains(messages[0], "minimum 0 and maximum -5 accept no value") {
+		t.Errorf("unexpected errors %v", messages)
+	}
+}
+
+func TestIntegerBoundsYAML(t *testing.T) {
+	documents := generate(t, Generator{IntegerBounds: true, Format: FormatYAML}, "../../testPkgs/validations")
+	definitions := documents["validations.yaml"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Sizes"].(map[string]interface{})["properties"].(map[string]interface{})
+	large := properties["large"].(map[string]interface{})
+	if fmt.Sprint(large["maximum"]) != "18446744073709551615" {
+		t.Errorf("unexpected maximum of large: %v", large)
+	}
+}
+
+func TestIntegerBoundsUnset(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/validations")
+	definitions := documents["validations.json"][definitionsKeyword].(map[string]interface{})
+	small := definitions["Sizes"].(map[string]interface{})["properties"].(map[string]interface{})["small"].(map[string]interface{})
+	if _, ok := small["minimum"]; ok {
+		t.Errorf("unexpected bounds without the option: %v", small)
+	}
+	port := definitions["Port"].(map[string]interface{})
+	if fmt.Sprint(port["maximum"]) != "70000" {
+		t.Errorf("unexpected maximum of Port: %v", port)
+	}
+}
+
+func TestExampleIntegerBounds(t *testing.T) {
+	doc := document{
+		"type":     "object",
+		"required": []interface{}{"large", "ratio"},
+		"properties": map[string]interface{}{
+			"large": map[string]interface{}{"type": "integer", "minimum": json.Number("0"), "maximum": json.Number("18446744073709551615")},
+			"ratio": map[string]interface{}{"type": "integer", "minimum": json.Number("-9223372036854775808"), "maximum": json.Number("9223372036854775807")},
+		},
+	}
+	e := &exampleGenerator{docName: "doc.json", doc: doc, maximal: true, expanding: make(map[string]int)}
+	value, err := e.value(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateExample(doc, value); err != nil {
+		t.Error(err)
+	}
+	example := value.(map[string]interface{})
+	if fmt.Sprint(example["large"]) != "18446744073709551615" || fmt.Sprint(example["ratio"]) != "9223372036854775807" {
+		t.Errorf("unexpected example %v", example)
+	}
+}
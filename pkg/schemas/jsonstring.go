@@ -0,0 +1,120 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"go/types"
+	"log"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	// stringOption is the json tag option encoding a number, a boolean or a string as a JSON string
+	stringOption = "string"
+
+	numberStringPattern  = `^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`
+	booleanStringPattern = `^(true|false)$`
+	quotedStringPattern  = `^"([^"\\]|\\.)*"$`
+)
+
+// numericMarkers are the markers that only apply to numbers, which a number
+// encoded as a string does not satisfy
+var numericMarkers = []string{
+	"kubebuilder:validation:Minimum",
+	"kubebuilder:validation:Maximum",
+	"kubebuilder:validation:ExclusiveMinimum",
+	"kubebuilder:validation:ExclusiveMaximum",
+	"kubebuilder:validation:MultipleOf",
+}
+
+// stringEncodedPattern returns the pattern of the values of a type encoded as JSON strings with
+// the string option, which encoding/json only applies to numbers, booleans and strings, or
+// to pointers to them
+func stringEncodedPattern(typ types.Type) (string, bool) {
+	if pointer, isPointer := typ.(*types.Pointer); isPointer {
+		typ = pointer.Elem()
+	}
+	basic, isBasic := typ.Underlying().(*types.Basic)
+	if !isBasic {
+		return Empty, false
+	}
+	info := basic.Info()
+	switch {
+	case info&types.IsUnsigned != 0:
+		return unsignedKeyPattern, true
+	case info&types.IsInteger != 0:
+		return integerKeyPattern, true
+	case info&types.IsFloat != 0:
+		return numberStringPattern, true
+	case info&types.IsBoolean != 0:
+		return booleanStringPattern, true
+	case info&types.IsString != 0:
+		return quotedStringPattern, true
+	}
+	return Empty, false
+}
+
+// stringEncodedToSchema replaces the schema of a field with the string option with that of a
+// string matching the encoded values. The description and nullability are kept, and so are
+// the enum, const and default, encoded too, and the other keywords of the context. Markers
+// of the field or of its type that only apply to numbers are reported as warnings, as they
+// can not be enforced on the string.
+func stringEncodedToSchema(ctx *schemaContext, field markers.FieldInfo, props *apiext.JSONSchemaProps) *apiext.JSONSchemaProps {
+	pattern, applies := stringEncodedPattern(ctx.pkg.TypesInfo.TypeOf(field.RawField.Type))
+	if !applies {
+		return props
+	}
+	for _, name := range numericMarkers {
+		if field.Markers.Get(name) != nil {
+			log.Printf("%s: warning: the %s marker of field %s can not be enforced, as it is encoded as a string",
+				ctx.pkg.Fset.Position(field.RawField.Pos()), name, field.Name)
+		}
+	}
+	if typeIdent, isNamed := namedTypeIdent(ctx, field.RawField.Type); isNamed {
+		typeMarkers := ctx.schemaRequester.TypeMarkersFor(typeIdent)
+		for _, name := range numericMarkers {
+			if typeMarkers.Get(name) != nil {
+				log.Printf("%s: warning: the %s marker of type %s of field %s can not be enforced, as it is encoded as a string",
+					ctx.pkg.Fset.Position(field.RawField.Pos()), name, typeIdent.Name, field.Name)
+			}
+		}
+	}
+
+	// the exact bounds of integer kinds do not apply to the string
+	keywords := ctx.schemaKeywords()
+	delete(keywords, minimumKeyword)
+	delete(keywords, maximumKeyword)
+	encoded := &apiext.JSONSchemaProps{
+		Type:        "string",
+		Pattern:     pattern,
+		Description: props.Description,
+		Nullable:    props.Nullable,
+	}
+	for _, value := range props.Enum {
+		encoded.Enum = append(encoded.Enum, apiext.JSON{Raw: encodedJSON(value.Raw)})
+	}
+	if props.Default != nil {
+		encoded.Default = &apiext.JSON{Raw: encodedJSON(props.Default.Raw)}
+	}
+	if value, ok := keywords[constKeyword]; ok {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			ctx.pkg.AddError(loader.ErrFromNode(err, field.RawField))
+			return props
+		}
+		keywords[constKeyword] = string(raw)
+	}
+	return encoded
+}
+
+// encodedJSON returns a JSON value encoded as a JSON string
+func encodedJSON(raw []byte) []byte {
+	// Note: marshaling a string does not fail
+	encoded, _ := json.Marshal(string(raw))
+	return encoded
+}
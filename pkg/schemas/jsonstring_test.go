@@ -0,0 +1,77 @@
+package schemas
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestStringEncoded(t *testing.T) {
+	var warnings bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&warnings)
+	documents := generate(t, Generator{}, "../../testPkgs/encoded")
+	if !strings.Contains(warnings.String(), "encoded.go:15:2: warning: the kubebuilder:validation:Minimum marker of field Count") {
+		t.Errorf("expected a warning for the Minimum marker, got %q", warnings.String())
+	}
+	if !strings.Contains(warnings.String(), "warning: the kubebuilder:validation:Maximum marker of type Level of field Level") {
+		t.Errorf("expected a warning for the Maximum marker of Level, got %q", warnings.String())
+	}
+
+	definitions := documents["encoded.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Encoded"].(map[string]interface{})["properties"].(map[string]interface{})
+	if count := properties["count"].(map[string]interface{}); count["type"] != "string" || count["description"] != "Count of items" {
+		t.Errorf("unexpected count %v", count)
+	}
+	if _, ok := properties["detail"].(map[string]interface{})["$ref"]; !ok {
+		t.Errorf("unexpected detail %v", properties["detail"])
+	}
+	// the default and the const are encoded too
+	if limit := properties["limit"].(map[string]interface{}); limit["default"] != "5" {
+		t.Errorf("unexpected limit %v", limit)
+	}
+	if version := properties["version"].(map[string]interface{}); version[constKeyword] != "1" {
+		t.Errorf("unexpected version %v", version)
+	}
+
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Encoded",
+		definitionsKeyword: definitions,
+	})
+	valid := map[string]interface{}{"count": "0", "enabled": "true", "name": `"a \"b\""`, "level": "7", "mode": "2",
+		"ratio": "-1.5e3", "detail": map[string]interface{}{"size": 1}, "version": "1"}
+	for _, test := range []struct {
+		name  string
+		value interface{}
+		valid bool
+	}{
+		{"count", "0", true},
+		{"count", 0, false},
+		{"count", "01", false},
+		{"ratio", "0.5", true},
+		{"ratio", "0.", false},
+		{"enabled", true, false},
+		{"enabled", "yes", false},
+		{"name", "a", false},
+		{"level", "-1", false},
+		{"mode", "3", false},
+		{"version", 1, false},
+		{"version", "2", false},
+	} {
+		instance := make(map[string]interface{})
+		for name, value := range valid {
+			instance[name] = value
+		}
+		instance[test.name] = test.value
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%s %v: expected valid %v", test.name, test.value, test.valid)
+		}
+	}
+}
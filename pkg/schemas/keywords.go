@@ -0,0 +1,147 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keywordTable holds the keywords that apiext.JSONSchemaProps has no field for, of the schema
+// of a type and of its subschemas, by the JSON pointer of the subschema in the schema. Schemas
+// are copied as they are built, so the keywords are kept aside until the schema is converted
+// to a document.
+type keywordTable map[string]map[string]interface{}
+
+// KeywordMarker is a marker setting keywords that apiext.JSONSchemaProps has no field for
+type KeywordMarker interface {
+	// ApplyToKeywords sets the keywords of the schema the marker applies to
+	ApplyToKeywords(keywords map[string]interface{}) error
+}
+
+// SubschemaKeywordMarker is a SchemaMarker setting a subschema, which may have keywords
+// that apiext.JSONSchemaProps has no field for
+type SubschemaKeywordMarker interface {
+	SchemaMarker
+	// SubschemaKeywords returns the keyword of the subschema in the schema the marker applies
+	// to, and the keywords of the subschema that apiext.JSONSchemaProps has no field for
+	SubschemaKeywords() (string, keywordTable)
+}
+
+// escapePointer encodes a JSON pointer token
+var escapePointer = strings.NewReplacer("~", "~0", "/", "~1")
+
+// keywordsAt returns the keywords of the subschema at a JSON pointer
+func (t keywordTable) keywordsAt(pointer string) map[string]interface{} {
+	keywords, ok := t[pointer]
+	if !ok {
+		keywords = make(map[string]interface{})
+		t[pointer] = keywords
+	}
+	return keywords
+}
+
+// copyTo copies the keywords of the subschemas below the JSON pointer from into another
+// table, below the JSON pointer to
+func (t keywordTable) copyTo(other keywordTable, from, to string) {
+	for pointer, keywords := range t {
+		if pointer != from && !strings.HasPrefix(pointer, from+"/") {
+			continue
+		}
+		for keyword, value := range keywords {
+			other.keywordsAt(to + strings.TrimPrefix(pointer, from))[keyword] = value
+		}
+	}
+}
+
+// pointerTo returns the JSON pointer of the given tokens
+func pointerTo(tokens ...string) string {
+	var pointer strings.Builder
+	for _, token := range tokens {
+		pointer.WriteString("/" + escapePointer.Replace(token))
+	}
+	return pointer.String()
+}
+
+// at returns a copy of the context for the subschema at the given JSON pointer tokens
+// below the schema of the context
+func (c *schemaContext) at(tokens ...string) *schemaContext {
+	at := *c
+	at.path += pointerTo(tokens...)
+	return &at
+}
+
+// in returns a copy of the context generating its schema in place of the schema of another
+// context, such as a type argument written in the context of another type
+func (c *schemaContext) in(place *schemaContext) *schemaContext {
+	in := *c
+	in.keywords = place.keywords
+	in.path = place.path
+	return &in
+}
+
+// schemaKeywords returns the keywords of the schema of the context
+func (c *schemaContext) schemaKeywords() map[string]interface{} {
+	return c.keywords.keywordsAt(c.path)
+}
+
+// setKeyword sets a keyword that apiext.JSONSchemaProps has no field for on the schema of the context
+func (c *schemaContext) setKeyword(keyword string, value interface{}) {
+	c.schemaKeywords()[keyword] = value
+}
+
+// applyKeywords sets the keywords of a table in a schema and its subschemas. The keywords of
+// subschemas the schema no longer has, such as removed properties, are left out.
+func applyKeywords(schema map[string]interface{}, table keywordTable) error {
+	pointers := make([]string, 0, len(table))
+	for pointer := range table {
+		pointers = append(pointers, pointer)
+	}
+	// subschemas carried by keywords, such as propertyNames, are set before their own keywords
+	sort.Strings(pointers)
+	for _, pointer := range pointers {
+		subschema, ok := valueAt(schema, pointer).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for keyword, value := range table[pointer] {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			var generic interface{}
+			if err := unmarshalJSON(raw, &generic); err != nil {
+				return err
+			}
+			subschema[keyword] = generic
+		}
+	}
+	return nil
+}
+
+// valueAt returns the value at a JSON pointer in a generic JSON value, or nil if there is none
+func valueAt(value interface{}, pointer string) interface{} {
+	if pointer == Empty {
+		return value
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		switch current := value.(type) {
+		case map[string]interface{}:
+			value = current[unescapePointer.Replace(token)]
+		case document:
+			value = current[unescapePointer.Replace(token)]
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil
+			}
+			value = current[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,37 @@
+package schemas
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestApplyKeywords(t *testing.T) {
+	schema := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"a/b": map[string]interface{}{"type": "object"},
+		},
+		"oneOf": []interface{}{map[string]interface{}{}},
+	}
+	table := make(keywordTable)
+	table.keywordsAt(Empty)[propertyNamesKeyword] = map[string]interface{}{"pattern": "^a"}
+	table.keywordsAt(pointerTo(propertyNamesKeyword))[deprecatedKeyword] = true
+	table.keywordsAt(pointerTo("properties", "a/b"))[readOnlyKeyword] = true
+	table.keywordsAt(pointerTo("oneOf", "0"))[constKeyword] = 1
+	// removed subschemas have no keywords
+	table.keywordsAt(pointerTo("properties", "removed"))[writeOnlyKeyword] = true
+
+	if err := applyKeywords(schema, table); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"a/b": map[string]interface{}{"type": "object", readOnlyKeyword: true},
+		},
+		"oneOf":              []interface{}{map[string]interface{}{constKeyword: json.Number("1")}},
+		propertyNamesKeyword: map[string]interface{}{"pattern": "^a", deprecatedKeyword: true},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("unexpected schema %v", schema)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	propertyNamesKeyword = "propertyNames"
+	// integerKeyPattern and unsignedKeyPattern match integers as encoding/json writes them in map keys
+	integerKeyPattern  = "^-?(0|[1-9][0-9]*)$"
+	unsignedKeyPattern = "^(0|[1-9][0-9]*)$"
+)
+
+// Open coded go/types representation of encoding.TextMarshaler
+var textMarshaler = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "MarshalText",
+		types.NewSignatureType(nil, nil, nil, nil,
+			types.NewTuple(
+				types.NewVar(token.NoPos, nil, "", types.NewSlice(types.Universe.Lookup("byte").Type())),
+				types.NewVar(token.NoPos, nil, "", types.Universe.Lookup("error").Type())), false)),
+}, nil).Complete()
+
+// implementsTextMarshaler tells if encoding/json writes values of a type with their MarshalText method
+func implementsTextMarshaler(typ types.Type) bool {
+	return types.Implements(typ, textMarshaler) || types.Implements(types.NewPointer(typ), textMarshaler)
+}
+
+// isString tells if the underlying type of a type is a string
+func isString(typ types.Type) bool {
+	basic, isBasic := typ.Underlying().(*types.Basic)
+	return isBasic && basic.Info()&types.IsString != 0
+}
+
+// mapKeyToSchema creates the schema of the property names of a map, as encoding/json writes
+// its keys: strings as they are, text marshalers as their text and integers in decimal. Named
+// string types constrain the property names when their schema constrains the strings. It
+// returns nil when any property name is accepted.
+func mapKeyToSchema(ctx *schemaContext, key ast.Expr) (*apiext.JSONSchemaProps, error) {
+	keyExpr, keyCtx := ctx.resolve(key)
+	keyType := keyCtx.pkg.TypesInfo.TypeOf(keyExpr)
+	basic, isBasic := keyType.Underlying().(*types.Basic)
+	switch {
+	case isString(keyType):
+		if !constrainedString(keyCtx, keyExpr) {
+			return nil, nil
+		}
+		return typeToSchema(keyCtx.ForInfo(&markers.TypeInfo{}).in(ctx.at(propertyNamesKeyword)), keyExpr), nil
+	case types.Implements(keyType, textMarshaler):
+		return typeToSchema(keyCtx.ForInfo(&markers.TypeInfo{}).in(ctx.at(propertyNamesKeyword)), keyExpr), nil
+	case isBasic && basic.Info()&types.IsUnsigned != 0:
+		return &apiext.JSONSchemaProps{Pattern: unsignedKeyPattern}, nil
+	case isBasic && basic.Info()&types.IsInteger != 0:
+		return &apiext.JSONSchemaProps{Pattern: integerKeyPattern}, nil
+	default:
+		return nil, fmt.Errorf("map keys must be strings, integers or encoding.TextMarshaler, not %s", keyType.String())
+	}
+}
+
+// constrainedString tells if a type is a named string type whose schema does not accept any string
+func constrainedString(ctx *schemaContext, expr ast.Expr) bool {
+	typeIdent, isNamed := namedTypeIdent(ctx, expr)
+	if !isNamed {
+		return false
+	}
+	ctx.requestSchema(typeIdent)
+	schema, known := ctx.schemaRequester.SchemaFor(typeIdent)
+	return known && (len(schema.Enum) > 0 || schema.Pattern != Empty || schema.MinLength != nil || schema.MaxLength != nil)
+}
+
+// textMarshalerToSchema creates the schema of a type written by encoding/json with its MarshalText
+// method, which is a string whatever the type is, unless markers tell otherwise
+func textMarshalerToSchema(ctx *schemaContext) *apiext.JSONSchemaProps {
+	schema := &apiext.JSONSchemaProps{Type: "string"}
+	describe(ctx, schema, typeDoc(ctx.info))
+	applyMarkers(ctx, ctx.info.Markers, schema, ctx.info.RawSpec.Type)
+	return schema
+}
+
+// setPropertyNames sets the schema of the property names of a map
+func setPropertyNames(ctx *schemaContext, propertyNames *apiext.JSONSchemaProps) {
+	if propertyNames == nil {
+		return
+	}
+	ctx.setKeyword(propertyNamesKeyword, propertyNames)
+}
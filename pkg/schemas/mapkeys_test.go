@@ -0,0 +1,75 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestMapKeys(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/mapkeys")
+	definitions := documents["mapkeys.json"][definitionsKeyword].(map[string]interface{})
+	coordinates := definitions["Coordinates"].(map[string]interface{})
+	if coordinates["type"] != "string" || coordinates["properties"] != nil {
+		t.Errorf("unexpected schema of a text marshaler %v", coordinates)
+	}
+
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Grid",
+		definitionsKeyword: definitions,
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"rows": map[string]interface{}{"1": "a", "-2": "b"},
+			"columns": map[string]interface{}{"0": "a"}, "cells": map[string]interface{}{"1,-2": "a"}}, true},
+		{map[string]interface{}{"rows": map[string]interface{}{"a": "a"},
+			"columns": map[string]interface{}{}, "cells": map[string]interface{}{}}, false},
+		{map[string]interface{}{"rows": map[string]interface{}{},
+			"columns": map[string]interface{}{"-1": "a"}, "cells": map[string]interface{}{}}, false},
+		{map[string]interface{}{"rows": map[string]interface{}{},
+			"columns": map[string]interface{}{}, "cells": map[string]interface{}{"1": "a"}}, false},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v", test.instance, test.valid)
+		}
+	}
+}
+
+func TestNamedStringMapKeys(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/mapkeys")
+	definitions := documents["mapkeys.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Quotas"].(map[string]interface{})["properties"].(map[string]interface{})
+	if labels := properties["labels"].(map[string]interface{}); labels[propertyNamesKeyword] != nil {
+		t.Errorf("unexpected property names of an unconstrained string type %v", labels[propertyNamesKeyword])
+	}
+
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Quotas",
+		definitionsKeyword: definitions,
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"regions": map[string]interface{}{"east": 1},
+			"zones": map[string]interface{}{"east-1": 1}, "labels": map[string]interface{}{"any": "a"}}, true},
+		{map[string]interface{}{"regions": map[string]interface{}{"north": 1},
+			"zones": map[string]interface{}{}, "labels": map[string]interface{}{}}, false},
+		{map[string]interface{}{"regions": map[string]interface{}{},
+			"zones": map[string]interface{}{"east": 1}, "labels": map[string]interface{}{}}, false},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v", test.instance, test.valid)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const markdownExtension = ".md"
+
+// constraintKeywords are the validation keywords listed in the constraints column, in order
+var constraintKeywords = []string{"enum", "format", "pattern", "minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum",
+	"multipleOf", "minLength", "maxLength", "minItems", "maxItems", "uniqueItems", "minProperties", "maxProperties",
+	"nullable", "default", deprecatedKeyword}
+
+// anchorUnsafeChars matches characters dropped from headings to build their anchors
+var anchorUnsafeChars = regexp.MustCompile(`[^a-z0-9 _-]`)
+
+// MarkdownGenerator generates a Markdown reference of the documents that Generator generates.
+// It writes a page for each document with a section for each definition.
+type MarkdownGenerator struct {
+	OutputDir string
+
+	// AllowDangerousTypes allows float32 and float64 fields, see Generator.
+	AllowDangerousTypes *bool `marker:",optional"`
+}
+
+func (MarkdownGenerator) CheckFilter() loader.NodeFilter {
+	return Generator{}.CheckFilter()
+}
+
+func (MarkdownGenerator) RegisterMarkers(into *markers.Registry) error {
+	return Generator{}.RegisterMarkers(into)
+}
+
+func (g MarkdownGenerator) Generate(ctx *genall.GenerationContext) error {
+	context, documents, err := Generator{AllowDangerousTypes: g.AllowDangerousTypes}.generateDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	allowUnknownFields(documents)
+	pageLinks(documents)
+
+	if err := os.MkdirAll(g.OutputDir, os.ModePerm); err != nil {
+		return err
+	}
+	for docName, doc := range documents {
+		page := &markdownPage{context: context, docName: docName, doc: doc}
+		outputFilepath := filepath.Clean(filepath.Join(g.OutputDir, objectName(docName)+markdownExtension))
+		if err := os.WriteFile(outputFilepath, []byte(page.render()), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markdownPage renders a generated document as Markdown
+type markdownPage struct {
+	context *GeneratorContext
+	docName string
+	doc     document
+}
+
+// render returns the Markdown source of the page
+func (p *markdownPage) render() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s\n", objectName(p.docName))
+	if p.context.objectDocuments[p.docName] {
+		root := make(map[string]interface{})
+		for keyword, value := range p.doc {
+			if keyword != definitionsKeyword && keyword != defsKeyword && keyword != "title" {
+				root[keyword] = value
+			}
+		}
+		p.writeSection(&out, objectName(p.docName), root)
+	}
+	definitions := definitionsOf(p.doc)
+	for _, definitionName := range sortedKeys(definitions) {
+		schema, _ := definitions[definitionName].(map[string]interface{})
+		p.writeSection(&out, displayName(p.docName, definitionName), schema)
+	}
+	return out.String()
+}
+
+// writeSection writes the section of a definition: its description, followed by a table
+// of its fields or, for definitions that are not objects, by its type and constraints
+func (p *markdownPage) writeSection(out *strings.Builder, heading string, schema map[string]interface{}) {
+	fmt.Fprintf(out, "\n## %s\n", heading)
+	if description, ok := schema["description"].(string); ok && description != Empty {
+		fmt.Fprintf(out, "\n%s\n", strings.TrimSpace(description))
+	}
+
+	var fields []map[string]interface{}
+	var required []interface{}
+	collectFields(schema, &fields, &required)
+	var inherited []string
+	for _, item := range allOf(schema) {
+		if ref, ok := item.(map[string]interface{})["$ref"].(string); ok {
+			inherited = append(inherited, p.link(ref))
+		}
+	}
+	if len(inherited) > 0 {
+		fmt.Fprintf(out, "\nIncludes the fields of %s.\n", strings.Join(inherited, ", "))
+	}
+
+	if len(fields) == 0 {
+		fmt.Fprintf(out, "\n**Type:** %s\n", p.typeOf(schema))
+		if constraints := constraintsOf(schema); constraints != Empty {
+			fmt.Fprintf(out, "\n**Constraints:** %s\n", constraints)
+		}
+		return
+	}
+
+	isRequired := make(map[string]bool)
+	for _, name := range required {
+		isRequired[fmt.Sprint(name)] = true
+	}
+	out.WriteString("\n| Field | Type | Required | Constraints | Description |\n")
+	out.WriteString("|-------|------|----------|-------------|-------------|\n")
+	for _, properties := range fields {
+		for _, name := range sortedKeys(properties) {
+			property, _ := properties[name].(map[string]interface{})
+			requiredCell := "no"
+			if isRequired[name] {
+				requiredCell = "yes"
+			}
+			description, _ := property["description"].(string)
+			fmt.Fprintf(out, "| `%s` | %s | %s | %s | %s |\n", name, p.typeOf(property), requiredCell,
+				constraintsOf(property), tableCell(description))
+		}
+	}
+}
+
+// collectFields gathers the properties of a schema, including those of inline allOf schemas
+func collectFields(schema map[string]interface{}, fields *[]map[string]interface{}, required *[]interface{}) {
+	if properties, ok := schema["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+		*fields = append(*fields, properties)
+	}
+	if list, ok := schema["required"].([]interface{}); ok {
+		*required = append(*required, list...)
+	}
+	for _, item := range allOf(schema) {
+		if inline, ok := item.(map[string]interface{}); ok && inline["$ref"] == nil {
+			collectFields(inline, fields, required)
+		}
+	}
+}
+
+// typeOf describes the type of a schema, linking referenced definitions
+func (p *markdownPage) typeOf(schema map[string]interface{}) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		return p.link(ref)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if branches, ok := schema[keyword].([]interface{}); ok && schema["properties"] == nil {
+			var types []string
+			for _, branch := range branches {
+				types = append(types, p.typeOf(branch.(map[string]interface{})))
+			}
+			return strings.Join(types, " or ")
+		}
+	}
+	switch schema["type"] {
+	case "array":
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			return "[]" + p.typeOf(items)
+		}
+		return "array"
+	case "object":
+		if values, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			return "map[string]" + p.typeOf(values)
+		}
+		return "object"
+	case nil:
+		if len(allOf(schema)) > 0 {
+			return "object"
+		}
+		return "any"
+	default:
+		return fmt.Sprint(schema["type"])
+	}
+}
+
+// link returns a Markdown link to the definition a reference points to
+func (p *markdownPage) link(ref string) string {
+	name, target, ok := sectionOf(p.docName, ref)
+	if !ok {
+		return "`" + ref + "`"
+	}
+	return fmt.Sprintf("[%s](%s)", name, target)
+}
+
+// sectionOf returns the heading and the target of the section of the definition a reference
+// points to. References to other documents target their page, as TypeRefLink references
+// their file.
+func sectionOf(fromDocument, ref string) (string, string, bool) {
+	docName, definitionName, ok := parseDefinitionRef(ref, fromDocument)
+	if !ok {
+		return Empty, Empty, false
+	}
+	name := displayName(docName, definitionName)
+	target := "#" + anchorFor(name)
+	if docName != fromDocument {
+		target = objectName(docName) + markdownExtension + target
+	}
+	return name, target, true
+}
+
+// pageLinks replaces the doc links of the descriptions of the documents, which point
+// into the documents, with links to the sections of the pages
+func pageLinks(documents map[string]document) {
+	for docName, doc := range documents {
+		walkSchemas(doc, func(schema map[string]interface{}) {
+			replaceDocLinks(schema, func(text, ref string) string {
+				_, target, ok := sectionOf(docName, ref)
+				if !ok || !resolves(documents, docName, ref) {
+					return text
+				}
+				return "[" + text + "](" + target + ")"
+			})
+		})
+	}
+}
+
+// displayName turns qualified definition names into Go style package qualified names
+func displayName(docName, definitionName string) string {
+	if docName == externalDocumentName {
+		return strings.NewReplacer("~1", "/", "~0", ".").Replace(definitionName)
+	}
+	return definitionName
+}
+
+// anchorFor returns the anchor Markdown renderers generate for a heading
+func anchorFor(heading string) string {
+	anchor := anchorUnsafeChars.ReplaceAllString(strings.ToLower(heading), Empty)
+	return strings.ReplaceAll(anchor, " ", "-")
+}
+
+// constraintsOf lists the validation keywords of a schema
+func constraintsOf(schema map[string]interface{}) string {
+	var constraints []string
+	for _, keyword := range constraintKeywords {
+		value, ok := schema[keyword]
+		if !ok || value == false {
+			continue
+		}
+		if keyword == "enum" {
+			var values []string
+			descriptions, _ := schema[enumDescriptionsKeyword].([]interface{})
+			for i, item := range value.([]interface{}) {
+				encoded, _ := json.Marshal(item)
+				text := "`" + string(encoded) + "`"
+				if i < len(descriptions) && descriptions[i] != Empty {
+					text += fmt.Sprintf(" (%s)", descriptions[i])
+				}
+				values = append(values, text)
+			}
+			constraints = append(constraints, "enum: "+strings.Join(values, ", "))
+			continue
+		}
+		if keyword == deprecatedKeyword {
+			text := deprecatedKeyword
+			if message, _ := schema[deprecatedMessageKeyword].(string); message != Empty {
+				text += ": " + message
+			}
+			constraints = append(constraints, text)
+			continue
+		}
+		encoded, _ := json.Marshal(value)
+		constraints = append(constraints, fmt.Sprintf("%s: `%s`", keyword, encoded))
+	}
+	return tableCell(strings.Join(constraints, "\n"))
+}
+
+// tableCell escapes text for use in a table cell
+func tableCell(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "|", "\\|")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
@@ -0,0 +1,95 @@
+package schemas
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+)
+
+func TestMarkdownGenerator(t *testing.T) {
+	outputDir := t.TempDir()
+	var generator genall.Generator = &MarkdownGenerator{OutputDir: outputDir}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/fybrikobject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.Run() {
+		t.Fatal("generator failed with errors")
+	}
+	source, err := os.ReadFile(filepath.Join(outputDir, "sample_crd.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"# sample_crd\n",
+		"| `field1` | [Type1](#type1) | yes |  |  |",
+		"## Type1",
+		"| `type1f1` | [SchemaType1](schemapkg.md#schematype1) | no |  |  |",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+	source, err = os.ReadFile(filepath.Join(outputDir, "external.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[fybrik.io/json-schema-generator/testPkgs/fybrikobject.Type1](#fybrikiojson-schema-generatortestpkgsfybrikobjecttype1)"
+	if !strings.Contains(string(source), expected) {
+		t.Errorf("expected %q in:\n%s", expected, source)
+	}
+}
+
+func TestMarkdownDocLinks(t *testing.T) {
+	outputDir := t.TempDir()
+	var generator genall.Generator = &MarkdownGenerator{OutputDir: outputDir}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/godoc", "../../testPkgs/embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.Run() {
+		t.Fatal("generator failed with errors")
+	}
+	source, err := os.ReadFile(filepath.Join(outputDir, "godoc.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"Catalog lists [Asset](#asset) items.",
+		"  - a status, see [embedded.Status](embedded.md#status)\n",
+		"deprecated: see [embedded.Status](embedded.md#status) instead.",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+	if strings.Contains(string(source), "#/definitions/") {
+		t.Errorf("unexpected JSON pointer link in:\n%s", source)
+	}
+}
+
+func TestMarkdownSection(t *testing.T) {
+	page := &markdownPage{context: &GeneratorContext{objectDocuments: map[string]bool{}}, docName: "pkg.json"}
+	var out strings.Builder
+	page.writeSection(&out, "Connection", map[string]interface{}{
+		"description": "A connection",
+		"type":        "object",
+		"required":    []interface{}{"port"},
+		"properties": map[string]interface{}{
+			"port": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 65535, "description": "Port | number"},
+			"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b"}}},
+		},
+	})
+	for _, expected := range []string{
+		"\n## Connection\n\nA connection\n",
+		"| `port` | integer | yes | minimum: `1`<br>maximum: `65535` | Port \\| number |",
+		"| `tags` | []string | no |  |  |",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, out.String())
+		}
+	}
+}
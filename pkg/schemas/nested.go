@@ -0,0 +1,55 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"go/ast"
+
+	"sigs.k8s.io/controller-tools/pkg/crd"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// structFields returns the fields of a struct type, which are only collected by the
+// markers package for the type of a type declaration and not for anonymous structs
+func structFields(ctx *schemaContext, structType *ast.StructType) []markers.FieldInfo {
+	if ctx.info.RawSpec != nil && ctx.info.RawSpec.Type == structType {
+		return ctx.info.Fields
+	}
+	var fields []markers.FieldInfo
+	for _, field := range structType.Fields.List {
+		info := markers.FieldInfo{
+			Tag:      loader.ParseAstTag(field.Tag),
+			Markers:  ctx.schemaRequester.MarkersFor(ctx.pkg, field),
+			RawField: field,
+		}
+		if field.Names == nil {
+			fields = append(fields, info)
+		}
+		for _, name := range field.Names {
+			info.Name = name.Name
+			fields = append(fields, info)
+		}
+	}
+	return fields
+}
+
+// MarkersFor returns the markers of a node of a package
+func (context *GeneratorContext) MarkersFor(pkg *loader.Package, node ast.Node) markers.MarkerValues {
+	nodeMarkers, err := context.parser.Collector.MarkersInPackage(pkg)
+	if err != nil {
+		pkg.AddError(err)
+		return nil
+	}
+	return nodeMarkers[node]
+}
+
+// TypeMarkersFor returns the markers of a type
+func (context *GeneratorContext) TypeMarkersFor(typ crd.TypeIdent) markers.MarkerValues {
+	info, _, known := context.typeInfoFor(typ)
+	if !known {
+		return nil
+	}
+	return info.Markers
+}
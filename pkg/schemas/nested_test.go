@@ -0,0 +1,32 @@
+package schemas
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestAnonymousStructs(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/embedded")
+	definitions := documents["embedded.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Deployment"].(map[string]interface{})["properties"].(map[string]interface{})
+
+	spec := properties["spec"].(map[string]interface{})
+	if spec["type"] != "object" || spec["description"] != "Spec is the desired state" {
+		t.Errorf("unexpected spec %v", spec)
+	}
+	specProperties := spec["properties"].(map[string]interface{})
+	replicas := specProperties["replicas"].(map[string]interface{})
+	if fmt.Sprint(replicas["minimum"]) != "1" || replicas["description"] != "Replicas is the number of instances" {
+		t.Errorf("unexpected replicas %v", replicas)
+	}
+	port := specProperties["ports"].(map[string]interface{})["items"].(map[string]interface{})
+	if !reflect.DeepEqual(port["required"], []interface{}{"port"}) {
+		t.Errorf("unexpected port %v", port)
+	}
+
+	volume := properties["volumes"].(map[string]interface{})["additionalProperties"].(map[string]interface{})
+	if !reflect.DeepEqual(volume["required"], []interface{}{"path"}) {
+		t.Errorf("unexpected volume %v", volume)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"go/ast"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	// NullableNone leaves pointer fields as their value
+	NullableNone = "none"
+	// NullableKeyword makes pointer fields nullable with the OpenAPI nullable keyword
+	NullableKeyword = "nullable"
+	// NullableType makes pointer fields nullable by adding null to their type
+	NullableType = "type"
+
+	nullableMarkerName = "fybrik:validation:nullable"
+)
+
+// nullableMarker makes a field nullable, or not with nullable=false, whatever the nullability policy
+var nullableMarker = markers.Must(markers.MakeDefinition(nullableMarkerName, markers.DescribesField, (*bool)(nil)))
+
+// validateNullable checks the nullability policy of pointer fields
+func validateNullable(policy, openAPI string) error {
+	switch policy {
+	case Empty, NullableNone, NullableKeyword:
+		return nil
+	case NullableType:
+		if openAPI == OpenAPI30 {
+			return fmt.Errorf("OpenAPI %s has no null type, use the %s nullability instead", OpenAPI30, NullableKeyword)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported nullability %q, expected one of %s, %s or %s",
+			policy, NullableNone, NullableKeyword, NullableType)
+	}
+}
+
+// nullablePolicy tells if a nullability policy makes pointer fields nullable
+func nullablePolicy(policy string) bool {
+	return policy == NullableKeyword || policy == NullableType
+}
+
+// nullableField tells if a field is nullable: if it is a pointer and the policy makes pointers
+// nullable, unless its nullable marker tells otherwise. The second result is false when the
+// nullability of the field is left as its markers set it.
+func nullableField(ctx *schemaContext, field markers.FieldInfo) (bool, bool) {
+	if nullable, marked := field.Markers.Get(nullableMarkerName).(*bool); marked {
+		return nullable == nil || *nullable, true
+	}
+	expr, _ := ctx.resolve(field.RawField.Type)
+	_, isPointer := expr.(*ast.StarExpr)
+	return true, isPointer && ctx.nullablePointers
+}
+
+// nullableRef wraps the reference of a nullable schema in an allOf, as OpenAPI 3.0 ignores
+// the keywords next to a reference, nullable included
+func nullableRef(props *apiext.JSONSchemaProps) {
+	if !props.Nullable || props.Ref == nil {
+		return
+	}
+	props.AllOf = append([]apiext.JSONSchemaProps{{Ref: props.Ref}}, props.AllOf...)
+	props.Ref = nil
+}
+
+// nullableToTypes adds null to the types of the nullable schemas of a document
+func nullableToTypes(doc document) {
+	walkSchemas(doc, nullableToType)
+}
@@ -0,0 +1,86 @@
+package schemas
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestNullableNone(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/embedded")
+	definitions := documents["embedded.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Limits"].(map[string]interface{})["properties"].(map[string]interface{})
+	for name, property := range properties {
+		typ := property.(map[string]interface{})["type"]
+		if (name == "name") != reflect.DeepEqual(typ, []interface{}{"string", "null"}) {
+			t.Errorf("unexpected type %v of %s", typ, name)
+		}
+	}
+}
+
+func TestNullableKeyword(t *testing.T) {
+	documents := generate(t, Generator{Nullable: NullableKeyword, OpenAPI: OpenAPI30}, "../../testPkgs/embedded")
+	schemas := documents[openAPIDocumentName]["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	properties := schemas["Limits"].(map[string]interface{})["properties"].(map[string]interface{})
+	for name, nullable := range map[string]bool{"cpu": true, "memory": false, "name": true, "status": true} {
+		if (properties[name].(map[string]interface{})["nullable"] == true) != nullable {
+			t.Errorf("expected nullable %v for %s", nullable, name)
+		}
+	}
+	// OpenAPI 3.0 ignores the keywords next to a reference
+	status := properties["status"].(map[string]interface{})
+	expected := []interface{}{map[string]interface{}{"$ref": componentsRef + "Status"}}
+	if _, ok := status["$ref"]; ok || !reflect.DeepEqual(status["allOf"], expected) {
+		t.Errorf("unexpected status %v", status)
+	}
+}
+
+func TestNullableKeywordJSONSchema(t *testing.T) {
+	documents := generate(t, Generator{Nullable: NullableKeyword}, "../../testPkgs/embedded")
+	definitions := documents["embedded.json"][definitionsKeyword].(map[string]interface{})
+	walkSchemas(definitions["Limits"].(map[string]interface{}), func(schema map[string]interface{}) {
+		if _, ok := schema["nullable"]; ok {
+			t.Errorf("unexpected nullable keyword in %v", schema)
+		}
+	})
+	properties := definitions["Limits"].(map[string]interface{})["properties"].(map[string]interface{})
+	expected := []interface{}{
+		map[string]interface{}{"$ref": "#/definitions/Status"},
+		map[string]interface{}{"type": "null"},
+	}
+	if status := properties["status"].(map[string]interface{}); !reflect.DeepEqual(status["anyOf"], expected) {
+		t.Errorf("unexpected status %v", status)
+	}
+}
+
+func TestNullableType(t *testing.T) {
+	documents := generate(t, Generator{Nullable: NullableType}, "../../testPkgs/embedded")
+	definitions := documents["embedded.json"][definitionsKeyword].(map[string]interface{})
+	properties := definitions["Limits"].(map[string]interface{})["properties"].(map[string]interface{})
+	if cpu := properties["cpu"].(map[string]interface{}); !reflect.DeepEqual(cpu["type"], []interface{}{"integer", "null"}) {
+		t.Errorf("unexpected cpu %v", cpu)
+	}
+
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Limits",
+		definitionsKeyword: definitions,
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"cpu": nil, "name": nil, "status": nil, "region": nil}, true},
+		{map[string]interface{}{"cpu": 1, "name": "a", "status": map[string]interface{}{"phase": "a"}, "region": "east"}, true},
+		{map[string]interface{}{"name": "a", "memory": nil}, false},
+		{map[string]interface{}{"name": "a", "region": "north"}, false},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v", test.instance, test.valid)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Supported values for Generator.OpenAPI
+const (
+	OpenAPI30 = "3.0"
+	OpenAPI31 = "3.1"
+)
+
+const (
+	openAPIDocumentName = "openapi.json"
+	componentsRef       = "#/components/schemas/"
+)
+
+// openAPIVersions maps a supported OpenAPI version to the version written in the document
+var openAPIVersions = map[string]string{
+	OpenAPI30: "3.0.3",
+	OpenAPI31: "3.1.0",
+}
+
+// unsupportedOpenAPI30Keywords are the JSON schema keywords that OpenAPI 3.0 schemas do not
+// have, which are dropped from their components
+var unsupportedOpenAPI30Keywords = []string{ifKeyword, thenKeyword, elseKeyword, containsKeyword, minContainsKeyword,
+	maxContainsKeyword, propertyNamesKeyword, dependentRequiredKeyword, dependentSchemasKeyword}
+
+// unsafeComponentChars matches characters that OpenAPI does not allow in component names
+var unsafeComponentChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// validateOpenAPI checks that the OpenAPI version is supported and not combined with a dialect
+func validateOpenAPI(version, dialect string) error {
+	if version == Empty {
+		return nil
+	}
+	if _, ok := openAPIVersions[version]; !ok {
+		return fmt.Errorf("unsupported OpenAPI version %q, expected %s or %s", version, OpenAPI30, OpenAPI31)
+	}
+	if dialect != Empty {
+		return fmt.Errorf("OpenAPI output can not be combined with the %s dialect", dialect)
+	}
+	return nil
+}
+
+// componentName builds the name under components.schemas for a definition of a generated document.
+// Definitions of package documents keep their name, qualified names of external.json are turned
+// into dotted package paths, and definitions of object documents are prefixed by the object name.
+func (context *GeneratorContext) componentName(documentName, definitionName string) string {
+	name := definitionName
+	switch {
+	case documentName == externalDocumentName:
+		name = strings.NewReplacer("~1", ".", "~0", ".").Replace(definitionName)
+	case context.objectDocuments[documentName]:
+		name = objectName(documentName) + "." + definitionName
+	}
+	return unsafeComponentChars.ReplaceAllString(name, "_")
+}
+
+// objectName returns the name given by the object marker to an object document
+func objectName(documentName string) string {
+	return strings.TrimSuffix(documentName, ".json")
+}
+
+// openAPIDocument merges the generated documents into the components of a single OpenAPI document
+func (context *GeneratorContext) openAPIDocument(documents map[string]document) (document, error) {
+	version := context.generator.OpenAPI
+	documentNames := sortedDocumentNames(documents)
+
+	// Name all components first, as references may point to any document
+	components, err := context.collectComponents(documents, documentNames)
+	if err != nil {
+		return nil, err
+	}
+	if err := components.resolveRefs(documentNames); err != nil {
+		return nil, err
+	}
+	if version == OpenAPI31 {
+		// 3.1 schemas are JSON schema 2020-12 schemas
+		for _, schema := range components.schemas {
+			toJSONSchemaKeywords(schema.(map[string]interface{}))
+		}
+	} else {
+		components.toOpenAPI30()
+	}
+	return openAPIWrapper(version, components.schemas), nil
+}
+
+// openAPIComponents are the schemas of the components of an OpenAPI document,
+// named after the definitions of the documents they come from
+type openAPIComponents struct {
+	// schemas are the component schemas, by component name
+	schemas map[string]interface{}
+	// names are the component names, by document and definition name
+	names map[string]map[string]string
+	// origins are the documents of the components, by component name
+	origins map[string]string
+}
+
+// add adds the component of a definition of a document
+func (components *openAPIComponents) add(docName, definitionName, component string, schema map[string]interface{}) error {
+	if origin, exists := components.origins[component]; exists {
+		return fmt.Errorf("component %q is generated both for %s and %s", component, origin, docName)
+	}
+	components.origins[component] = docName
+	if components.names[docName] == nil {
+		components.names[docName] = make(map[string]string)
+	}
+	components.names[docName][definitionName] = component
+	components.schemas[component] = schema
+	return nil
+}
+
+// collectComponents makes a component of every definition of the documents, and of the
+// root schema of every object document
+func (context *GeneratorContext) collectComponents(documents map[string]document, documentNames []string) (*openAPIComponents, error) {
+	components := &openAPIComponents{
+		schemas: make(map[string]interface{}),
+		names:   make(map[string]map[string]string),
+		origins: make(map[string]string),
+	}
+	for _, docName := range documentNames {
+		doc := documents[docName]
+		definitions := definitionsOf(doc)
+		for _, definitionName := range sortedKeys(definitions) {
+			schema, _ := definitions[definitionName].(map[string]interface{})
+			if err := components.add(docName, definitionName, context.componentName(docName, definitionName), schema); err != nil {
+				return nil, err
+			}
+		}
+		if context.objectDocuments[docName] {
+			root := make(map[string]interface{})
+			for keyword, value := range doc {
+				if keyword != definitionsKeyword {
+					root[keyword] = value
+				}
+			}
+			root["title"] = objectName(docName)
+			// the root schema is not a definition, so it is registered under the empty name
+			if err := components.add(docName, Empty, unsafeComponentChars.ReplaceAllString(objectName(docName), "_"), root); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return components, nil
+}
+
+// resolveRefs points all references to components, and fails on the references
+// to definitions that are not components
+func (components *openAPIComponents) resolveRefs(documentNames []string) error {
+	var unresolved []string
+	for _, docName := range documentNames {
+		for _, component := range components.names[docName] {
+			schema := components.schemas[component].(map[string]interface{})
+			rewriteRefs(schema, func(ref string) string {
+				refDocument, definitionName, ok := parseDefinitionRef(ref, docName)
+				if ok {
+					if target, found := components.names[refDocument][definitionName]; found {
+						return componentsRef + target
+					}
+				}
+				unresolved = append(unresolved, ref)
+				return ref
+			})
+		}
+	}
+	if len(unresolved) > 0 {
+		sort.Strings(unresolved)
+		return fmt.Errorf("unresolved references in OpenAPI components: %s", strings.Join(unresolved, ", "))
+	}
+	return nil
+}
+
+// toOpenAPI30 adjusts the keywords of the components to OpenAPI 3.0, and warns about
+// the keywords it has not
+func (components *openAPIComponents) toOpenAPI30() {
+	dropped := make(map[string][]string)
+	for _, component := range sortedKeys(components.schemas) {
+		schema := components.schemas[component].(map[string]interface{})
+		walkSchemas(schema, constToEnum)
+		for _, keyword := range dropKeywords(schema, unsupportedOpenAPI30Keywords) {
+			dropped[keyword] = append(dropped[keyword], component)
+		}
+	}
+	for _, keyword := range unsupportedOpenAPI30Keywords {
+		if components := dropped[keyword]; len(components) > 0 {
+			log.Printf("warning: OpenAPI %s has no %s keyword, it is dropped from %s",
+				OpenAPI30, keyword, strings.Join(components, ", "))
+		}
+	}
+}
+
+// openAPIWrapper returns an OpenAPI document of the given version that only carries components
+func openAPIWrapper(version string, schemas map[string]interface{}) document {
+	doc := document{
+		"openapi": openAPIVersions[version],
+		// info is required by OpenAPI, the document only carries components
+		"info": map[string]interface{}{
+			"title":   "Generated schemas",
+			"version": "1.0.0",
+		},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+	if version == OpenAPI30 {
+		// paths is only optional since 3.1
+		doc["paths"] = map[string]interface{}{}
+	}
+	return doc
+}
+
+// sortedDocumentNames returns the names of the documents in a stable order
+func sortedDocumentNames(documents map[string]document) []string {
+	documentNames := make([]string, 0, len(documents))
+	for docName := range documents {
+		documentNames = append(documentNames, docName)
+	}
+	sort.Strings(documentNames)
+	return documentNames
+}
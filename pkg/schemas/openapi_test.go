@@ -0,0 +1,75 @@
+package schemas
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI30(t *testing.T) {
+	documents := generate(t, Generator{OpenAPI: OpenAPI30}, "../../testPkgs/fybrikobject")
+	if len(documents) != 1 {
+		t.Fatalf("expected a single document, got %d", len(documents))
+	}
+	doc := documents[openAPIDocumentName]
+	if doc["openapi"] != "3.0.3" {
+		t.Errorf("unexpected version %v", doc["openapi"])
+	}
+	if _, ok := doc["paths"]; !ok {
+		t.Error("3.0 documents require paths")
+	}
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	for _, name := range []string{"SchemaType1", "sample_crd", "sample_crd.Type1", "fybrik.io.json-schema-generator.testPkgs.fybrikobject.Type2"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("component %s is missing", name)
+		}
+	}
+	for name, schema := range schemas {
+		rewriteRefs(schema.(map[string]interface{}), func(ref string) string {
+			target := strings.TrimPrefix(ref, componentsRef)
+			if _, ok := schemas[target]; !ok || target == ref {
+				t.Errorf("%s: reference %s does not point to a component", name, ref)
+			}
+			return ref
+		})
+	}
+	root := schemas["sample_crd"].(map[string]interface{})
+	ref := root["properties"].(map[string]interface{})["field1"].(map[string]interface{})["$ref"]
+	if ref != componentsRef+"sample_crd.Type1" {
+		t.Errorf("unexpected reference %v", ref)
+	}
+}
+
+func TestOpenAPI31Keywords(t *testing.T) {
+	context := &GeneratorContext{generator: Generator{OpenAPI: OpenAPI31}, objectDocuments: map[string]bool{}}
+	doc, err := context.openAPIDocument(map[string]document{
+		"pkg.json": {
+			"definitions": map[string]interface{}{
+				"A": map[string]interface{}{"type": "integer", "nullable": true, "minimum": 0, "exclusiveMinimum": true},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc["paths"]; ok {
+		t.Error("3.1 documents do not need paths")
+	}
+	schema := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})["A"].(map[string]interface{})
+	if schema["exclusiveMinimum"] != 0 || schema["nullable"] != nil || len(schema["type"].([]interface{})) != 2 {
+		t.Errorf("unexpected schema %v", schema)
+	}
+}
+
+func TestOpenAPIUnresolvedReference(t *testing.T) {
+	context := &GeneratorContext{generator: Generator{OpenAPI: OpenAPI30}, objectDocuments: map[string]bool{}}
+	_, err := context.openAPIDocument(map[string]document{
+		"pkg.json": {
+			"definitions": map[string]interface{}{
+				"A": map[string]interface{}{"$ref": "other.json#/definitions/B"},
+			},
+		},
+	})
+	if err == nil {
+		t.Error("expected an error for a reference to a missing definition")
+	}
+}
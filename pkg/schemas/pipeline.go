@@ -0,0 +1,157 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+// generation is the output of the generator, as the steps of the pipeline change it
+type generation struct {
+	// documents are the schema documents, by their name
+	documents map[string]document
+	// files are written next to the documents, by their path
+	files map[string]interface{}
+	// profiles are added to the documents once they are bundled
+	profiles map[string]document
+}
+
+// step is a step of the pipeline, which transforms the documents or adds files
+type step func(out *generation) error
+
+// steps returns the pipeline that turns the generated documents into the output,
+// in the order the steps must run. Steps of options that are not set do nothing.
+func (context *GeneratorContext) steps() []step {
+	return []step{
+		context.addCRDSchemas,
+		context.addHelmSchemas,
+		context.addExamples,
+		context.generateProfiles,
+		context.bundleObjectDocuments,
+		context.addProfiles,
+		context.inlineReferences,
+		context.toOpenAPI,
+		context.toDialect,
+		context.unlinkUnresolved,
+	}
+}
+
+// addCRDSchemas adds the structural schemas of the object documents for the CRD option.
+// It must run while the documents still have their x-kubernetes-preserve-unknown-fields.
+func (context *GeneratorContext) addCRDSchemas(out *generation) error {
+	if !context.generator.CRD {
+		return nil
+	}
+	crdSchemas, err := context.crdSchemas(out.documents)
+	if err != nil {
+		return err
+	}
+	addFiles(out, crdSchemas)
+	return nil
+}
+
+// addHelmSchemas drops x-kubernetes-preserve-unknown-fields, and adds the values schema
+// of each type with helm marker
+func (context *GeneratorContext) addHelmSchemas(out *generation) error {
+	allowUnknownFields(out.documents)
+	helmSchemas, err := context.helmSchemas(out.documents)
+	if err != nil {
+		return err
+	}
+	addFiles(out, helmSchemas)
+	return nil
+}
+
+// addExamples adds the example instances of the object documents for the Examples option
+func (context *GeneratorContext) addExamples(out *generation) error {
+	if !context.generator.Examples {
+		return nil
+	}
+	examples, err := context.exampleDocuments(out.documents)
+	if err != nil {
+		return err
+	}
+	addFiles(out, examples)
+	return nil
+}
+
+// generateProfiles generates the profiles of the object documents for the Profiles option,
+// from the documents as they are before bundling
+func (context *GeneratorContext) generateProfiles(out *generation) error {
+	if !context.generator.Profiles {
+		return nil
+	}
+	profiles, err := context.profileDocuments(out.documents)
+	out.profiles = profiles
+	return err
+}
+
+// bundleObjectDocuments makes the object documents self-contained for the Bundle option
+func (context *GeneratorContext) bundleObjectDocuments(out *generation) error {
+	if !context.generator.Bundle {
+		return nil
+	}
+	docs, err := context.bundleDocuments(out.documents)
+	out.documents = docs
+	return err
+}
+
+// addProfiles adds the profiles, which are object documents from here on
+func (context *GeneratorContext) addProfiles(out *generation) error {
+	for docName, doc := range out.profiles {
+		out.documents[docName] = doc
+		context.objectDocuments[docName] = true
+	}
+	return nil
+}
+
+// inlineReferences inlines every reference for the Dereference option
+func (context *GeneratorContext) inlineReferences(out *generation) error {
+	if !context.generator.Dereference {
+		return nil
+	}
+	docs, err := context.dereferenceDocuments(out.documents)
+	out.documents = docs
+	return err
+}
+
+// toOpenAPI replaces the documents with a single OpenAPI document for the OpenAPI option
+func (context *GeneratorContext) toOpenAPI(out *generation) error {
+	if context.generator.OpenAPI == Empty {
+		return nil
+	}
+	openAPI, err := context.openAPIDocument(out.documents)
+	if err != nil {
+		return err
+	}
+	out.documents = map[string]document{openAPIDocumentName: openAPI}
+	return nil
+}
+
+// toDialect names the documents after the output format and writes them in the keywords
+// of the output dialect
+func (context *GeneratorContext) toDialect(out *generation) error {
+	g := context.generator
+	out.documents = renameDocuments(out.documents, g.Format)
+	for docName, doc := range out.documents {
+		// the nullable keyword is only known to OpenAPI 3.0
+		if g.OpenAPI != OpenAPI30 {
+			nullableToTypes(doc)
+		}
+		if g.OpenAPI == Empty && (g.Dialect == Empty || g.Dialect == DialectDraft07) {
+			toDraft07(docName, doc)
+		}
+		applyDialect(doc, g.Dialect, g.BaseURI+docName)
+	}
+	return nil
+}
+
+// unlinkUnresolved leaves the doc links to the definitions that dereferencing removed as text
+func (context *GeneratorContext) unlinkUnresolved(out *generation) error {
+	unlinkUnresolved(out.documents)
+	return nil
+}
+
+// addFiles adds files to be written next to the documents
+func addFiles(out *generation, files map[string]interface{}) {
+	for fileName, value := range files {
+		out.files[fileName] = value
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"sort"
+
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	readOnlyKeyword  = "readOnly"
+	writeOnlyKeyword = "writeOnly"
+
+	// the profiles of object documents, by operation
+	createProfile = "create"
+	updateProfile = "update"
+	readProfile   = "read"
+)
+
+// ReadOnly marks a field as set by the server, such as a status or a generated identifier
+type ReadOnly struct{}
+
+func (ReadOnly) ApplyToKeywords(keywords map[string]interface{}) error {
+	keywords[readOnlyKeyword] = true
+	return nil
+}
+
+// WriteOnly marks a field as never returned by the server, such as a secret
+type WriteOnly struct{}
+
+func (WriteOnly) ApplyToKeywords(keywords map[string]interface{}) error {
+	keywords[writeOnlyKeyword] = true
+	return nil
+}
+
+var (
+	readOnlyMarker  = markers.Must(markers.MakeDefinition("fybrik:validation:readOnly", markers.DescribesField, ReadOnly{}))
+	writeOnlyMarker = markers.Must(markers.MakeDefinition("fybrik:validation:writeOnly", markers.DescribesField, WriteOnly{}))
+)
+
+// profileKeywords are the keywords of the properties left out of each profile
+var profileKeywords = map[string]string{
+	createProfile: readOnlyKeyword,
+	updateProfile: readOnlyKeyword,
+	readProfile:   writeOnlyKeyword,
+}
+
+// profileDocuments returns the create, update and read profiles of each object document, by
+// document name. A profile is the bundled object document without the properties that are
+// not part of the operation: read-only properties for create and update, and write-only
+// properties for read.
+func (context *GeneratorContext) profileDocuments(documents map[string]document) (map[string]document, error) {
+	profiles := make(map[string]document)
+	for docName := range context.objectDocuments {
+		doc, err := context.bundle(docName, documents)
+		if err != nil {
+			return nil, err
+		}
+		for profile, keyword := range profileKeywords {
+			profileName := objectName(docName) + "." + profile + jsonExtension
+			profileDoc := deepCopyJSON(doc).(document)
+			profileDoc["title"] = profileName
+			walkSchemas(profileDoc, func(schema map[string]interface{}) {
+				removeProperties(schema, keyword)
+			})
+			profiles[profileName] = profileDoc
+		}
+	}
+	return profiles, nil
+}
+
+// removeProperties removes the properties with the given keyword from the properties and
+// required properties of a schema
+func removeProperties(schema map[string]interface{}, keyword string) {
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok || property[keyword] != true {
+			continue
+		}
+		delete(properties, name)
+		if required, ok := schema["required"].([]interface{}); ok {
+			var remaining []interface{}
+			for _, item := range required {
+				if item != name {
+					remaining = append(remaining, item)
+				}
+			}
+			if len(remaining) == 0 {
+				delete(schema, "required")
+			} else {
+				schema["required"] = remaining
+			}
+		}
+	}
+}
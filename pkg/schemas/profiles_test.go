@@ -0,0 +1,55 @@
+package schemas
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	documents := generate(t, Generator{Profiles: true}, "../../testPkgs/profiles")
+	for _, test := range []struct {
+		profile              string
+		properties, required []string
+		specProperties       []string
+	}{
+		{createProfile, []string{"spec"}, []string{"spec"}, []string{"owner", "password"}},
+		{updateProfile, []string{"spec"}, []string{"spec"}, []string{"owner", "password"}},
+		{readProfile, []string{"spec", "status"}, []string{"spec", "status"}, []string{"id", "owner"}},
+	} {
+		doc, exists := documents["account."+test.profile+".json"]
+		if !exists {
+			t.Fatalf("missing %s profile", test.profile)
+		}
+		if names := propertyNames(doc); !reflect.DeepEqual(names, test.properties) {
+			t.Errorf("%s: unexpected properties %v", test.profile, names)
+		}
+		if required := doc["required"]; len(required.([]interface{})) != len(test.required) {
+			t.Errorf("%s: unexpected required %v", test.profile, required)
+		}
+		var spec map[string]interface{}
+		for _, definition := range doc[definitionsKeyword].(map[string]interface{}) {
+			if _, isSpec := definition.(map[string]interface{})["properties"].(map[string]interface{})["owner"]; isSpec {
+				spec = definition.(map[string]interface{})
+			}
+		}
+		if names := propertyNames(spec); !reflect.DeepEqual(names, test.specProperties) {
+			t.Errorf("%s: unexpected spec properties %v", test.profile, names)
+		}
+	}
+
+	// the object document itself keeps every property
+	spec := documents["account.json"]["properties"].(map[string]interface{})["status"].(map[string]interface{})
+	if spec[readOnlyKeyword] != true {
+		t.Errorf("unexpected status %v", spec)
+	}
+}
+
+func propertyNames(schema map[string]interface{}) []string {
+	var names []string
+	for name := range schema["properties"].(map[string]interface{}) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
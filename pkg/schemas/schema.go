@@ -25,6 +25,7 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"strconv"
 	"strings"
 
 	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
@@ -66,6 +67,18 @@ type schemaRequester interface {
 	NeedSchemaFor(typ crd.TypeIdent)
 	// Note(roee88): TypeRefLink extracted to be controlled by caller
 	TypeRefLink(from *loader.Package, to crd.TypeIdent) string
+	// Implementations discovers the loaded types that implement an interface
+	Implementations(iface *types.Interface) []crd.TypeIdent
+	// Instantiate registers an instantiation of a generic type under the given name
+	Instantiate(generic crd.TypeIdent, name string, inst instance) (crd.TypeIdent, error)
+	// SchemaFor returns the schema generated for a type
+	SchemaFor(typ crd.TypeIdent) (apiext.JSONSchemaProps, bool)
+	// KeywordsFor returns the keywords of the schema generated for a type
+	KeywordsFor(typ crd.TypeIdent) keywordTable
+	// MarkersFor returns the markers of a node of a package
+	MarkersFor(pkg *loader.Package, node ast.Node) markers.MarkerValues
+	// TypeMarkersFor returns the markers of a type
+	TypeMarkersFor(typ crd.TypeIdent) markers.MarkerValues
 }
 
 // schemaContext stores and provides information across a hierarchy of schema generation.
@@ -76,7 +89,25 @@ type schemaContext struct {
 	schemaRequester schemaRequester
 	PackageMarkers  markers.MarkerValues
 
+	// typeArgs are the type arguments of the type parameters in scope, by name
+	typeArgs map[string]typeArg
+
 	allowDangerousTypes bool
+	// flattenEmbedded merges inline fields into their struct instead of its allOf
+	flattenEmbedded bool
+	// nullablePointers makes pointer fields nullable
+	nullablePointers bool
+	// omitDeprecated leaves deprecated fields out of the schema
+	omitDeprecated bool
+	// integerBounds bounds integers by the values of their kind
+	integerBounds bool
+	// titles sets the title of schemas to the first sentence of their doc comment
+	titles bool
+
+	// keywords are the keywords of the schema of the type, which apiext.JSONSchemaProps has no field for
+	keywords keywordTable
+	// path is the JSON pointer of the schema being generated in the schema of the type
+	path string
 }
 
 // newSchemaContext constructs a new schemaContext for the given package and schema requester.
@@ -97,7 +128,15 @@ func (c *schemaContext) ForInfo(info *markers.TypeInfo) *schemaContext {
 		pkg:                 c.pkg,
 		info:                info,
 		schemaRequester:     c.schemaRequester,
+		typeArgs:            c.typeArgs,
 		allowDangerousTypes: c.allowDangerousTypes,
+		flattenEmbedded:     c.flattenEmbedded,
+		nullablePointers:    c.nullablePointers,
+		omitDeprecated:      c.omitDeprecated,
+		integerBounds:       c.integerBounds,
+		titles:              c.titles,
+		keywords:            c.keywords,
+		path:                c.path,
 	}
 }
 
@@ -130,12 +169,22 @@ func infoToSchema(ctx *schemaContext) *apiext.JSONSchemaProps {
 		if schema.Type != "" {
 			return schema
 		}
+	} else if obj != nil && implementsTextMarshaler(obj.Type()) && !isString(obj.Type()) {
+		// Note: text marshalers are written as strings, whatever their underlying type
+		return textMarshalerToSchema(ctx)
 	}
-	return typeToSchema(ctx, ctx.info.RawSpec.Type)
+	props := typeToSchema(ctx, ctx.info.RawSpec.Type)
+	// the typed constants of the package are the values of the type, unless given by markers
+	inferEnum(ctx, props)
+	return props
 }
 
 // applyMarkers applies schema markers to the given schema, respecting "apply first" markers.
 func applyMarkers(ctx *schemaContext, markerSet markers.MarkerValues, props *apiext.JSONSchemaProps, node ast.Node) {
+	// Note: the bounds of integer kinds are kept where markers set looser ones
+	minimum, maximum := props.Minimum, props.Maximum
+	defer keepTighterBounds(ctx, props, minimum, maximum, node)
+
 	// apply "apply first" markers first...
 	for _, markerValues := range markerSet {
 		for _, markerValue := range markerValues {
@@ -172,14 +221,30 @@ func applyMarkers(ctx *schemaContext, markerSet markers.MarkerValues, props *api
 		}
 	}
 
-	// Note(roee88): exchange x-kubernetes-preserve-unknown-fields with additionalProperties: true
-	if props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields {
-		if props.AdditionalProperties == nil {
-			props.AdditionalProperties = &apiext.JSONSchemaPropsOrBool{}
+	// ...and the markers setting keywords apiext.JSONSchemaProps has no field for
+	for _, markerValues := range markerSet {
+		for _, markerValue := range markerValues {
+			if err := applyKeywordMarker(ctx, markerValue); err != nil {
+				ctx.pkg.AddError(loader.ErrFromNode(err, node))
+			}
 		}
-		props.AdditionalProperties.Allows = true
-		props.XPreserveUnknownFields = nil
 	}
+
+	// Note(roee88): x-kubernetes-preserve-unknown-fields is kept here, for CRD schemas, and is
+	// exchanged with additionalProperties: true in the JSON schema documents (see allowUnknownFields)
+}
+
+// applyKeywordMarker keeps aside the keywords that a marker sets, on the schema of the context
+// or on the subschema the marker sets, if apiext.JSONSchemaProps has no field for them
+func applyKeywordMarker(ctx *schemaContext, markerValue interface{}) error {
+	switch marker := markerValue.(type) {
+	case KeywordMarker:
+		return marker.ApplyToKeywords(ctx.schemaKeywords())
+	case SubschemaKeywordMarker:
+		keyword, keywords := marker.SubschemaKeywords()
+		keywords.copyTo(ctx.keywords, Empty, ctx.at(keyword).path)
+	}
+	return nil
 }
 
 // typeToSchema creates a schema for the given AST type.
@@ -196,15 +261,25 @@ func typeToSchema(ctx *schemaContext, rawType ast.Expr) *apiext.JSONSchemaProps
 		props = mapToSchema(ctx, expr)
 	case *ast.StarExpr:
 		props = typeToSchema(ctx, expr.X)
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		props = instanceToSchema(ctx, expr)
 	case *ast.StructType:
 		props = structToSchema(ctx, expr)
+	case *ast.InterfaceType:
+		if !hasImplementationsMarker(ctx.info.Markers) {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("interfaces need a %s or %s marker",
+				implementationsMarkerName, discoverImplementationsMarkerName), rawType))
+			return &apiext.JSONSchemaProps{}
+		}
+		props = implementationsToSchema(ctx, ctx.info.Markers, expr)
 	default:
 		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("unsupported AST kind %T", expr), rawType))
 		// NB(directxman12): we explicitly don't handle interfaces
 		return &apiext.JSONSchemaProps{}
 	}
 
-	props.Description = ctx.info.Doc
+	// Note: a "Deprecated:" paragraph marks the schema as deprecated instead
+	describe(ctx, props, typeDoc(ctx.info))
 
 	applyMarkers(ctx, ctx.info.Markers, props, rawType)
 
@@ -224,10 +299,22 @@ func localNamedToSchema(ctx *schemaContext, ident *ast.Ident) *apiext.JSONSchema
 		if err != nil {
 			ctx.pkg.AddError(loader.ErrFromNode(err, ident))
 		}
-		return &apiext.JSONSchemaProps{
+		props := &apiext.JSONSchemaProps{
 			Type:   typ,
 			Format: format,
 		}
+		if ctx.integerBounds {
+			setIntegerBounds(ctx, props, basicInfo.Kind())
+		}
+		return props
+	}
+	if _, isParam := typeInfo.(*types.TypeParam); isParam {
+		argExpr, argCtx := ctx.resolve(ident)
+		if argExpr == ident {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("no type argument for type parameter %s", ident.Name), ident))
+			return &apiext.JSONSchemaProps{}
+		}
+		return typeToSchema(argCtx.ForInfo(&markers.TypeInfo{}).in(ctx), argExpr)
 	}
 	// NB(directxman12): if there are dot imports, this might be an external reference,
 	// so use typechecking info to get the actual object
@@ -268,7 +355,8 @@ func namedToSchema(ctx *schemaContext, named *ast.SelectorExpr) *apiext.JSONSche
 // arrayToSchema creates a schema for the items of the given array, dealing appropriately
 // with the special `[]byte` type (according to OpenAPI standards).
 func arrayToSchema(ctx *schemaContext, array *ast.ArrayType) *apiext.JSONSchemaProps {
-	eltType := ctx.pkg.TypesInfo.TypeOf(array.Elt)
+	eltExpr, eltCtx := ctx.resolve(array.Elt)
+	eltType := eltCtx.pkg.TypesInfo.TypeOf(eltExpr)
 	if eltType == byteType && array.Len == nil {
 		// byte slices are represented as base64-encoded strings
 		// (the format is defined in OpenAPI v3, but not JSON Schema)
@@ -278,7 +366,7 @@ func arrayToSchema(ctx *schemaContext, array *ast.ArrayType) *apiext.JSONSchemaP
 		}
 	}
 	// TODO(directxman12): backwards-compat would require access to markers from base info
-	items := typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), array.Elt)
+	items := typeToSchema(ctx.ForInfo(&markers.TypeInfo{}).at("items"), array.Elt)
 
 	return &apiext.JSONSchemaProps{
 		Type:  "array",
@@ -289,134 +377,207 @@ func arrayToSchema(ctx *schemaContext, array *ast.ArrayType) *apiext.JSONSchemaP
 // mapToSchema creates a schema for items of the given map.  Key types must eventually resolve
 // to string (other types aren't allowed by JSON, and thus the kubernetes API standards).
 func mapToSchema(ctx *schemaContext, mapType *ast.MapType) *apiext.JSONSchemaProps {
-	keyInfo := ctx.pkg.TypesInfo.TypeOf(mapType.Key)
-	// check that we've got a type that actually corresponds to a string
-	for keyInfo != nil {
-		switch typedKey := keyInfo.(type) {
-		case *types.Basic:
-			if typedKey.Info()&types.IsString == 0 {
-				ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("map keys must be strings, not %s", keyInfo.String()), mapType.Key))
-				return &apiext.JSONSchemaProps{}
-			}
-			keyInfo = nil // stop iterating
-		case *types.Named:
-			keyInfo = typedKey.Underlying()
-		default:
-			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("map keys must be strings, not %s", keyInfo.String()), mapType.Key))
-			return &apiext.JSONSchemaProps{}
-		}
+	// Note: keys are strings, integers or text marshalers, which constrain the property names
+	propertyNames, err := mapKeyToSchema(ctx, mapType.Key)
+	if err != nil {
+		ctx.pkg.AddError(loader.ErrFromNode(err, mapType.Key))
+		return &apiext.JSONSchemaProps{}
 	}
 
 	// TODO(directxman12): backwards-compat would require access to markers from base info
 	var valSchema *apiext.JSONSchemaProps
+	valCtx := ctx.ForInfo(&markers.TypeInfo{}).at("additionalProperties")
 	switch val := mapType.Value.(type) {
 	case *ast.Ident:
-		valSchema = localNamedToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
+		valSchema = localNamedToSchema(valCtx, val)
 	case *ast.SelectorExpr:
-		valSchema = namedToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
+		valSchema = namedToSchema(valCtx, val)
 	case *ast.ArrayType:
-		valSchema = arrayToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
+		valSchema = arrayToSchema(valCtx, val)
 	case *ast.StarExpr:
-		valSchema = typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
-	case *ast.MapType:
-		valSchema = typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), val)
+		valSchema = typeToSchema(valCtx, val)
+	case *ast.MapType, *ast.IndexExpr, *ast.IndexListExpr, *ast.StructType:
+		valSchema = typeToSchema(valCtx, val)
 	default:
 		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("not a supported map value type: %T", mapType.Value), mapType.Value))
 		return &apiext.JSONSchemaProps{}
 	}
 
-	return &apiext.JSONSchemaProps{
+	props := &apiext.JSONSchemaProps{
 		Type: "object",
 		AdditionalProperties: &apiext.JSONSchemaPropsOrBool{
 			Schema: valSchema,
 			Allows: true, /* set automatically by serialization, but useful for testing */
 		},
 	}
+	setPropertyNames(ctx, propertyNames)
+	return props
 }
 
 // structToSchema creates a schema for the given struct.  Embedded fields are placed in AllOf,
 // and can be flattened later with a Flattener.
-//
-//nolint:gocyclo
 func structToSchema(ctx *schemaContext, structType *ast.StructType) *apiext.JSONSchemaProps {
-	props := &apiext.JSONSchemaProps{
-		Type:       "object",
-		Properties: make(map[string]apiext.JSONSchemaProps),
+	schema := &structSchema{
+		props: &apiext.JSONSchemaProps{
+			Type:       "object",
+			Properties: make(map[string]apiext.JSONSchemaProps),
+		},
 	}
 
-	if ctx.info.RawSpec.Type != structType {
-		ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("encountered non-top-level struct (possibly embedded), those aren't allowed"), structType))
-		return props
+	// Note: anonymous structs, at any depth, are inline object schemas
+	for _, field := range structFields(ctx, structType) {
+		schema.addField(ctx, field)
 	}
 
-	for _, field := range ctx.info.Fields {
-		jsonTag, hasTag := field.Tag.Lookup("json")
-		if !hasTag {
-			// if the field doesn't have a JSON tag, it doesn't belong in output (and shouldn't exist in a serialized type)
-			ctx.pkg.AddError(loader.ErrFromNode(
-				fmt.Errorf("encountered struct field %q without JSON tag in type %q", field.Name, ctx.info.Name), field.RawField))
-			continue
-		}
-		jsonOpts := strings.Split(jsonTag, ",")
-		if len(jsonOpts) == 1 && jsonOpts[0] == "-" {
-			// skipped fields have the tag "-" (note that "-," means the field is named "-")
-			continue
-		}
+	flattenEmbedded(ctx, schema.props, schema.flattened)
 
-		inline := false
-		omitEmpty := false
-		for _, opt := range jsonOpts[1:] {
-			switch opt {
-			case "inline":
-				inline = true
-			case "omitempty":
-				omitEmpty = true
-			}
-		}
-		fieldName := jsonOpts[0]
-		inline = inline || fieldName == Empty // anonymous fields are inline fields in YAML/JSON
+	if ctx.info.Markers.Get(unionMarkerName) != nil {
+		unionToSchema(ctx, schema.props, schema.discriminator, schema.members)
+	}
 
-		// if no default required mode is set, default to required
-		defaultMode := Required
-		if ctx.PackageMarkers.Get("kubebuilder:validation:Optional") != nil {
-			defaultMode = Optional
-		}
+	return schema.props
+}
 
-		switch defaultMode {
-		// if this package isn't set to optional default...
-		case Required:
-			// ...everything that's not inline, omitempty, or explicitly optional is required
-			if !inline && !omitEmpty && field.Markers.Get("kubebuilder:validation:Optional") == nil && field.Markers.Get("optional") == nil {
-				props.Required = append(props.Required, fieldName)
-			}
+// structSchema is the schema of a struct, as its fields are added to it
+type structSchema struct {
+	props *apiext.JSONSchemaProps
+	// the discriminator and members of unions
+	discriminator string
+	members       []unionMember
+	// the inline fields merged into the struct
+	flattened []markers.FieldInfo
+}
 
-		// if this package isn't set to required default...
-		case Optional:
-			// ...everything that isn't explicitly required is optional
-			if field.Markers.Get("kubebuilder:validation:Required") != nil {
-				props.Required = append(props.Required, fieldName)
-			}
-		}
+// fieldTag is how a struct field is serialized, as its JSON tag tells
+type fieldTag struct {
+	name          string
+	inline        bool
+	omitEmpty     bool
+	stringEncoded bool
+}
 
-		var propSchema *apiext.JSONSchemaProps
-		if field.Markers.Get(crdmarkers.SchemalessName) != nil {
-			propSchema = &apiext.JSONSchemaProps{}
-		} else {
-			propSchema = typeToSchema(ctx.ForInfo(&markers.TypeInfo{}), field.RawField.Type)
+// addField adds a field to the schema of its struct: as a property, to AllOf if it is
+// inline, or to the fields to flatten
+func (schema *structSchema) addField(ctx *schemaContext, field markers.FieldInfo) {
+	tag, serialized := parseFieldTag(ctx, field)
+	if !serialized {
+		return
+	}
+	props := schema.props
+	if isRequiredField(ctx, field, tag) {
+		props.Required = append(props.Required, tag.name)
+	}
+
+	// Note: the keywords of a field are kept by its place in the struct schema. Flattened
+	// fields have no place, their schema is dropped and their keywords with it.
+	fieldCtx := ctx.ForInfo(&markers.TypeInfo{}).at("properties", tag.name)
+	if tag.inline && flattens(ctx, field) {
+		fieldCtx.keywords = make(keywordTable)
+	} else if tag.inline {
+		fieldCtx = ctx.ForInfo(&markers.TypeInfo{}).at("allOf", strconv.Itoa(len(props.AllOf)))
+	}
+	propSchema := fieldToSchema(fieldCtx, field, tag.stringEncoded)
+
+	if tag.inline {
+		if flattens(ctx, field) {
+			schema.flattened = append(schema.flattened, field)
+			return
 		}
-		propSchema.Description = field.Doc
+		props.AllOf = append(props.AllOf, *propSchema)
+		return
+	}
 
-		applyMarkers(ctx, field.Markers, propSchema, field.RawField)
+	if field.Markers.Get(unionDiscriminatorMarkerName) != nil {
+		schema.discriminator = tag.name
+	} else {
+		schema.members = append(schema.members, unionMember{name: tag.name, value: field.Name, field: field})
+	}
+	props.Properties[tag.name] = *propSchema
+}
+
+// parseFieldTag parses the JSON tag of a field. It returns false for the fields that
+// are not serialized, and for the deprecated fields that are omitted.
+func parseFieldTag(ctx *schemaContext, field markers.FieldInfo) (fieldTag, bool) {
+	jsonTag, hasTag := field.Tag.Lookup("json")
+	if !hasTag {
+		// if the field doesn't have a JSON tag, it doesn't belong in output (and shouldn't exist in a serialized type)
+		ctx.pkg.AddError(loader.ErrFromNode(
+			fmt.Errorf("encountered struct field %q without JSON tag in type %q", field.Name, ctx.info.Name), field.RawField))
+		return fieldTag{}, false
+	}
+	jsonOpts := strings.Split(jsonTag, ",")
+	if len(jsonOpts) == 1 && jsonOpts[0] == "-" {
+		// skipped fields have the tag "-" (note that "-," means the field is named "-")
+		return fieldTag{}, false
+	}
+	if ctx.omitDeprecated && isDeprecated(field.RawField.Doc) {
+		return fieldTag{}, false
+	}
 
-		if inline {
-			props.AllOf = append(props.AllOf, *propSchema)
-			continue
+	parsed := fieldTag{name: jsonOpts[0]}
+	for _, opt := range jsonOpts[1:] {
+		switch opt {
+		case "inline":
+			parsed.inline = true
+		case "omitempty":
+			parsed.omitEmpty = true
+		case stringOption:
+			parsed.stringEncoded = true
 		}
+	}
+	parsed.inline = parsed.inline || parsed.name == Empty // anonymous fields are inline fields in YAML/JSON
+	return parsed, true
+}
 
-		props.Properties[fieldName] = *propSchema
+// isRequiredField tells whether a field is a required property of its struct
+func isRequiredField(ctx *schemaContext, field markers.FieldInfo, tag fieldTag) bool {
+	// if no default required mode is set, default to required
+	defaultMode := Required
+	if ctx.PackageMarkers.Get("kubebuilder:validation:Optional") != nil {
+		defaultMode = Optional
 	}
 
-	return props
+	switch defaultMode {
+	// if this package isn't set to optional default...
+	case Required:
+		// ...everything that's not inline, omitempty, or explicitly optional is required
+		return !tag.inline && !tag.omitEmpty &&
+			field.Markers.Get("kubebuilder:validation:Optional") == nil && field.Markers.Get("optional") == nil
+
+	// if this package isn't set to required default...
+	case Optional:
+		// ...everything that isn't explicitly required is optional
+		return field.Markers.Get("kubebuilder:validation:Required") != nil
+	}
+	return false
+}
+
+// fieldToSchema creates the schema of a field, with its description and markers applied
+func fieldToSchema(fieldCtx *schemaContext, field markers.FieldInfo, stringEncoded bool) *apiext.JSONSchemaProps {
+	var propSchema *apiext.JSONSchemaProps
+	if field.Markers.Get(crdmarkers.SchemalessName) != nil {
+		propSchema = &apiext.JSONSchemaProps{}
+	} else if hasImplementationsMarker(field.Markers) {
+		propSchema = implementationsToSchema(fieldCtx, field.Markers, field.RawField.Type)
+	} else {
+		// Note: floats encoded as strings are safe
+		typeCtx := fieldCtx.ForInfo(&markers.TypeInfo{})
+		typeCtx.allowDangerousTypes = typeCtx.allowDangerousTypes || stringEncoded
+		propSchema = typeToSchema(typeCtx, field.RawField.Type)
+	}
+	describe(fieldCtx, propSchema, field.RawField.Doc)
+
+	applyMarkers(fieldCtx, field.Markers, propSchema, field.RawField)
+	// Note: pointers can be null, as nullability policy and markers tell
+	if nullable, set := nullableField(fieldCtx, field); set {
+		propSchema.Nullable = nullable
+	}
+	// Note: numbers, booleans and strings with the string option are encoded as JSON strings
+	if stringEncoded {
+		propSchema = stringEncodedToSchema(fieldCtx, field, propSchema)
+	}
+	nullableRef(propSchema)
+	return propSchema
 }
 
 // builtinToType converts builtin basic types to their equivalent JSON schema form.
@@ -0,0 +1,434 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	typeScriptExtension = ".d.ts"
+	typeScriptIndent    = "  "
+	typeScriptHeader    = "// Code generated by json-schema-generator. DO NOT EDIT.\n"
+)
+
+var (
+	// unsafeIdentifierChars matches characters that are not allowed in TypeScript identifiers
+	unsafeIdentifierChars = regexp.MustCompile(`[^a-zA-Z0-9_$]`)
+	// identifierName matches property names that can be written without quotes
+	identifierName = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*$`)
+)
+
+// TypeScriptGenerator generates TypeScript declarations for the types that Generator
+// generates JSON schemas for. It writes a module for each package with schema marker,
+// for each type with object marker and for the external types these reference.
+type TypeScriptGenerator struct {
+	OutputDir string
+
+	// AllowDangerousTypes allows float32 and float64 fields, see Generator.
+	AllowDangerousTypes *bool `marker:",optional"`
+}
+
+func (TypeScriptGenerator) CheckFilter() loader.NodeFilter {
+	return Generator{}.CheckFilter()
+}
+
+func (TypeScriptGenerator) RegisterMarkers(into *markers.Registry) error {
+	return Generator{}.RegisterMarkers(into)
+}
+
+func (g TypeScriptGenerator) Generate(ctx *genall.GenerationContext) error {
+	context, documents, err := Generator{AllowDangerousTypes: g.AllowDangerousTypes}.generateDocuments(ctx)
+	if err != nil {
+		return err
+	}
+	allowUnknownFields(documents)
+
+	modules := newTypeScriptModules(context, documents)
+	if err := os.MkdirAll(g.OutputDir, os.ModePerm); err != nil {
+		return err
+	}
+	for _, module := range modules {
+		outputFilepath := filepath.Clean(filepath.Join(g.OutputDir, module.name+typeScriptExtension))
+		if err := os.WriteFile(outputFilepath, []byte(module.render(modules)), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeScriptModule holds the declarations generated for a single document
+type typeScriptModule struct {
+	name    string
+	docName string
+	doc     document
+	// root is the identifier of the root schema of object documents
+	root string
+	// identifiers maps definition names to the identifiers they are declared as
+	identifiers map[string]string
+	// imports maps module names to the imported identifiers and their local aliases
+	imports map[string]map[string]string
+	// used are the identifiers declared or imported in the module
+	used map[string]bool
+}
+
+// newTypeScriptModules names the declarations of every document
+func newTypeScriptModules(context *GeneratorContext, documents map[string]document) map[string]*typeScriptModule {
+	modules := make(map[string]*typeScriptModule)
+	for docName, doc := range documents {
+		module := &typeScriptModule{
+			name:        objectName(docName),
+			docName:     docName,
+			doc:         doc,
+			identifiers: make(map[string]string),
+			imports:     make(map[string]map[string]string),
+			used:        make(map[string]bool),
+		}
+		if context.objectDocuments[docName] {
+			module.root = module.declare(pascalCase(module.name), module.name)
+		}
+		for _, definitionName := range sortedKeys(definitionsOf(doc)) {
+			module.identifiers[definitionName] = module.declare(typeScriptIdentifier(definitionName), definitionName)
+		}
+		modules[docName] = module
+	}
+	return modules
+}
+
+// declare reserves an identifier in the module, falling back to a name built from
+// the whole of qualified if the identifier is taken
+func (m *typeScriptModule) declare(identifier, qualified string) string {
+	if m.used[identifier] {
+		identifier = unsafeIdentifierChars.ReplaceAllString(strings.NewReplacer("~1", "_", "~0", "_").Replace(qualified), "_")
+	}
+	for base, i := identifier, 2; m.used[identifier]; i++ {
+		identifier = fmt.Sprintf("%s%d", base, i)
+	}
+	m.used[identifier] = true
+	return identifier
+}
+
+// typeScriptIdentifier derives an identifier from a definition name, dropping the
+// package path of qualified names
+func typeScriptIdentifier(definitionName string) string {
+	if i := strings.LastIndex(definitionName, "~0"); i != -1 {
+		definitionName = definitionName[i+len("~0"):]
+	}
+	return unsafeIdentifierChars.ReplaceAllString(definitionName, "_")
+}
+
+// pascalCase turns an object name such as sample_crd into SampleCrd
+func pascalCase(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, Empty)
+}
+
+// render returns the source of the module
+func (m *typeScriptModule) render(modules map[string]*typeScriptModule) string {
+	var declarations strings.Builder
+	if m.root != Empty {
+		root := make(map[string]interface{})
+		for keyword, value := range m.doc {
+			if keyword != definitionsKeyword && keyword != defsKeyword && keyword != "title" {
+				root[keyword] = value
+			}
+		}
+		m.writeDeclaration(&declarations, m.root, root, modules)
+	}
+	definitions := definitionsOf(m.doc)
+	for _, definitionName := range sortedKeys(definitions) {
+		schema, _ := definitions[definitionName].(map[string]interface{})
+		m.writeDeclaration(&declarations, m.identifiers[definitionName], schema, modules)
+	}
+
+	var out strings.Builder
+	out.WriteString(typeScriptHeader)
+	moduleNames := make([]string, 0, len(m.imports))
+	for moduleName := range m.imports {
+		moduleNames = append(moduleNames, moduleName)
+	}
+	sort.Strings(moduleNames)
+	if len(moduleNames) > 0 {
+		out.WriteString("\n")
+	}
+	for _, moduleName := range moduleNames {
+		var names []string
+		for identifier, alias := range m.imports[moduleName] {
+			if alias == identifier {
+				names = append(names, identifier)
+			} else {
+				names = append(names, identifier+" as "+alias)
+			}
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&out, "import { %s } from \"./%s\";\n", strings.Join(names, ", "), moduleName)
+	}
+	out.WriteString(declarations.String())
+	return out.String()
+}
+
+// writeDeclaration writes an exported interface, or a type alias for schemas that are not plain objects
+func (m *typeScriptModule) writeDeclaration(out *strings.Builder, identifier string, schema map[string]interface{},
+	modules map[string]*typeScriptModule) {
+	out.WriteString("\n")
+	m.writeTSDoc(out, Empty, schema, modules)
+
+	var extends []string
+	plainObject := schema["type"] == "object" && schema["enum"] == nil && !hasAlternatives(schema) && schema["nullable"] != true
+	if _, isMap := schema["additionalProperties"].(map[string]interface{}); isMap {
+		plainObject = false
+	}
+	for _, item := range allOf(schema) {
+		ref, isRef := item.(map[string]interface{})["$ref"].(string)
+		if !isRef || len(item.(map[string]interface{})) != 1 {
+			plainObject = false
+			break
+		}
+		extends = append(extends, m.reference(ref, modules))
+	}
+	if !plainObject {
+		fmt.Fprintf(out, "export type %s = %s;\n", identifier, m.typeExpr(schema, Empty, modules))
+		return
+	}
+	fmt.Fprintf(out, "export interface %s ", identifier)
+	if len(extends) > 0 {
+		fmt.Fprintf(out, "extends %s ", strings.Join(extends, ", "))
+	}
+	out.WriteString(m.objectBody(schema, Empty, modules))
+	out.WriteString("\n")
+}
+
+// hasAlternatives tells if a schema is one of the types of oneOf or anyOf. Objects with properties,
+// like unions, only use them to constrain which of their properties are set.
+func hasAlternatives(schema map[string]interface{}) bool {
+	return (schema["oneOf"] != nil || schema["anyOf"] != nil) && schema["properties"] == nil
+}
+
+// typeExpr returns the TypeScript type of a schema
+func (m *typeScriptModule) typeExpr(schema map[string]interface{}, indent string, modules map[string]*typeScriptModule) string {
+	var expr string
+	switch {
+	case schema["$ref"] != nil:
+		expr = m.reference(schema["$ref"].(string), modules)
+	case schema["enum"] != nil:
+		var literals []string
+		for _, value := range schema["enum"].([]interface{}) {
+			literal, _ := json.Marshal(value)
+			literals = append(literals, string(literal))
+		}
+		expr = strings.Join(literals, " | ")
+	case hasAlternatives(schema):
+		branches, _ := schema["oneOf"].([]interface{})
+		if branches == nil {
+			branches, _ = schema["anyOf"].([]interface{})
+		}
+		var types []string
+		for _, branch := range branches {
+			types = append(types, m.typeExpr(branch.(map[string]interface{}), indent, modules))
+		}
+		expr = strings.Join(types, " | ")
+	case schema["allOf"] != nil:
+		var types []string
+		for _, item := range allOf(schema) {
+			types = append(types, m.typeExpr(item.(map[string]interface{}), indent, modules))
+		}
+		if schema["properties"] != nil {
+			types = append(types, m.objectBody(schema, indent, modules))
+		}
+		expr = strings.Join(types, " & ")
+	default:
+		expr = m.typeExprOf(schema, schema["type"], indent, modules)
+	}
+	if schema["nullable"] == true {
+		expr += " | null"
+	}
+	return expr
+}
+
+// typeExprOf returns the TypeScript type for a value of the type keyword
+func (m *typeScriptModule) typeExprOf(schema map[string]interface{}, typ interface{}, indent string,
+	modules map[string]*typeScriptModule) string {
+	switch typ {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		if items == nil {
+			return "unknown[]"
+		}
+		item := m.typeExpr(items, indent, modules)
+		if strings.ContainsAny(item, " |&") && !strings.HasPrefix(item, "{") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		return m.objectBody(schema, indent, modules)
+	}
+	if types, ok := typ.([]interface{}); ok {
+		var exprs []string
+		for _, t := range types {
+			exprs = append(exprs, m.typeExprOf(schema, t, indent, modules))
+		}
+		return strings.Join(exprs, " | ")
+	}
+	if schema["properties"] != nil {
+		return m.objectBody(schema, indent, modules)
+	}
+	return "unknown"
+}
+
+// objectBody returns the TypeScript object type of a schema with properties,
+// marking the properties that are not required as optional
+func (m *typeScriptModule) objectBody(schema map[string]interface{}, indent string, modules map[string]*typeScriptModule) string {
+	properties, _ := schema["properties"].(map[string]interface{})
+	var valueType string
+	switch additional := schema["additionalProperties"].(type) {
+	case bool:
+		if additional {
+			valueType = "unknown"
+		}
+	case map[string]interface{}:
+		valueType = "unknown"
+		if len(properties) == 0 {
+			valueType = m.typeExpr(additional, indent+typeScriptIndent, modules)
+		}
+	}
+	if len(properties) == 0 && valueType == Empty {
+		return "{}"
+	}
+
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, name := range list {
+			required[fmt.Sprint(name)] = true
+		}
+	}
+	inner := indent + typeScriptIndent
+	var out strings.Builder
+	out.WriteString("{\n")
+	for _, name := range sortedKeys(properties) {
+		property, _ := properties[name].(map[string]interface{})
+		m.writeTSDoc(&out, inner, property, modules)
+		key := name
+		if !identifierName.MatchString(name) {
+			quoted, _ := json.Marshal(name)
+			key = string(quoted)
+		}
+		optional := "?"
+		if required[name] {
+			optional = Empty
+		}
+		if property[readOnlyKeyword] == true {
+			key = "readonly " + key
+		}
+		fmt.Fprintf(&out, "%s%s%s: %s;\n", inner, key, optional, m.typeExpr(property, inner, modules))
+	}
+	if valueType != Empty {
+		fmt.Fprintf(&out, "%s[key: string]: %s;\n", inner, valueType)
+	}
+	out.WriteString(indent + "}")
+	return out.String()
+}
+
+// reference returns the identifier for a referenced definition, importing it from its module if needed
+func (m *typeScriptModule) reference(ref string, modules map[string]*typeScriptModule) string {
+	docName, definitionName, ok := parseDefinitionRef(ref, m.docName)
+	target, found := modules[docName]
+	if !ok || !found {
+		return "unknown"
+	}
+	identifier, found := target.identifiers[definitionName]
+	if !found {
+		return "unknown"
+	}
+	if target == m {
+		return identifier
+	}
+	imported := m.imports[target.name]
+	if imported == nil {
+		imported = make(map[string]string)
+		m.imports[target.name] = imported
+	}
+	if alias, done := imported[identifier]; done {
+		return alias
+	}
+	alias := identifier
+	if m.used[alias] {
+		alias = m.declare(target.name+"_"+identifier, target.name+"_"+identifier)
+	} else {
+		m.used[alias] = true
+	}
+	imported[identifier] = alias
+	return alias
+}
+
+// writeTSDoc writes the description of a schema as a TSDoc comment, with its doc links
+// to definitions as links to their declarations. Lines keep their indentation, which
+// code blocks depend on.
+func (m *typeScriptModule) writeTSDoc(out *strings.Builder, indent string, schema map[string]interface{},
+	modules map[string]*typeScriptModule) {
+	schema = m.tsDocLinks(schema, modules)
+	description, _ := schema["description"].(string)
+	description = strings.TrimSpace(description)
+	if schema[deprecatedKeyword] == true {
+		message, _ := schema[deprecatedMessageKeyword].(string)
+		description = strings.TrimSpace(description + "\n\n@deprecated " + message)
+	}
+	if description == Empty {
+		return
+	}
+	description = strings.ReplaceAll(description, "*/", "*\\/")
+	fmt.Fprintf(out, "%s/**\n", indent)
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == Empty {
+			fmt.Fprintf(out, "%s *\n", indent)
+		} else {
+			fmt.Fprintf(out, "%s * %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(out, "%s */\n", indent)
+}
+
+// tsDocLinks returns a copy of a schema with the doc links of its descriptions replaced
+// by TSDoc links to the declarations of the definitions they point to
+func (m *typeScriptModule) tsDocLinks(schema map[string]interface{}, modules map[string]*typeScriptModule) map[string]interface{} {
+	linked := make(map[string]interface{}, len(schema))
+	for keyword, value := range schema {
+		linked[keyword] = value
+	}
+	replaceDocLinks(linked, func(text, ref string) string {
+		identifier := m.reference(ref, modules)
+		switch identifier {
+		case "unknown":
+			return text
+		case text:
+			return "{@link " + identifier + "}"
+		default:
+			return "{@link " + identifier + " | " + text + "}"
+		}
+	})
+	return linked
+}
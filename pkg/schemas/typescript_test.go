@@ -0,0 +1,107 @@
+package schemas
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/controller-tools/pkg/genall"
+)
+
+func TestTypeScriptGenerator(t *testing.T) {
+	outputDir := t.TempDir()
+	var generator genall.Generator = &TypeScriptGenerator{OutputDir: outputDir}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/fybrikobject")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.Run() {
+		t.Fatal("generator failed with errors")
+	}
+	for _, name := range []string{"schemapkg.d.ts", "sample_crd.d.ts", "external.d.ts"} {
+		if _, err := os.Stat(filepath.Join(outputDir, name)); err != nil {
+			t.Errorf("module %s is missing: %v", name, err)
+		}
+	}
+	source, err := os.ReadFile(filepath.Join(outputDir, "sample_crd.d.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`import { SchemaType1 } from "./schemapkg";`,
+		"export interface SampleCrd {\n  field1: Type1;\n}",
+		"  type1f1?: SchemaType1;",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+}
+
+func TestTypeScriptDocLinks(t *testing.T) {
+	outputDir := t.TempDir()
+	var generator genall.Generator = &TypeScriptGenerator{OutputDir: outputDir}
+	runtime, err := genall.Generators{&generator}.ForRoots("../../testPkgs/godoc", "../../testPkgs/embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.Run() {
+		t.Fatal("generator failed with errors")
+	}
+	source, err := os.ReadFile(filepath.Join(outputDir, "godoc.d.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		" * Catalog lists {@link Asset} items.",
+		" *   - a status, see {@link Status | embedded.Status}\n",
+		// code blocks keep their indentation
+		" * \tcatalog.Assets[\"name\"]\n",
+		"   * @deprecated see {@link Status | embedded.Status} instead.",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+}
+
+func TestTypeScriptDeclarations(t *testing.T) {
+	documents := map[string]document{
+		"pkg.json": {
+			"definitions": map[string]interface{}{
+				"Protocol": map[string]interface{}{"type": "string", "enum": []interface{}{"s3", "kafka"}, "description": "Protocol of a connection"},
+				"Connection": map[string]interface{}{
+					"type":     "object",
+					"required": []interface{}{"protocol"},
+					"allOf":    []interface{}{map[string]interface{}{"$ref": "#/definitions/Base"}},
+					"properties": map[string]interface{}{
+						"protocol": map[string]interface{}{"$ref": "#/definitions/Protocol"},
+						"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"labels": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": map[string]interface{}{"type": "integer"},
+							"description":          "Labels of the connection",
+						},
+						"x-name": map[string]interface{}{"type": "string", "nullable": true},
+					},
+				},
+				"Base": map[string]interface{}{"type": "object"},
+			},
+		},
+	}
+	modules := newTypeScriptModules(&GeneratorContext{objectDocuments: map[string]bool{}}, documents)
+	source := modules["pkg.json"].render(modules)
+	for _, expected := range []string{
+		"/**\n * Protocol of a connection\n */\nexport type Protocol = \"s3\" | \"kafka\";",
+		"export interface Connection extends Base {",
+		"  /**\n   * Labels of the connection\n   */\n  labels?: {\n    [key: string]: number;\n  };",
+		"  protocol: Protocol;",
+		"  tags?: string[];",
+		"  \"x-name\"?: string | null;",
+	} {
+		if !strings.Contains(source, expected) {
+			t.Errorf("expected %q in:\n%s", expected, source)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"fmt"
+	"strconv"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	unionMarkerName              = "union"
+	unionDiscriminatorMarkerName = "unionDiscriminator"
+	constKeyword                 = "const"
+)
+
+// unionMarkers are the Kubernetes markers of unions: a struct whose members, all of its
+// fields but the discriminator, are mutually exclusive
+var unionMarkers = []*markers.Definition{
+	markers.Must(markers.MakeDefinition(unionMarkerName, markers.DescribesType, struct{}{})),
+	markers.Must(markers.MakeDefinition(unionDiscriminatorMarkerName, markers.DescribesField, struct{}{})),
+}
+
+// unionMember is a member of a union
+type unionMember struct {
+	// name is the JSON name of the member
+	name string
+	// value is the value of the discriminator when the member is set, the Go name of the member
+	value string
+	field markers.FieldInfo
+}
+
+// unionToSchema adds to the schema of a union a oneOf branch for each member, requiring
+// that member and none of the others, and pinning the value of the discriminator if any
+func unionToSchema(ctx *schemaContext, props *apiext.JSONSchemaProps, discriminator string, members []unionMember) {
+	for _, member := range members {
+		if indexOf(member.name, props.Required) != -1 {
+			ctx.pkg.AddError(loader.ErrFromNode(fmt.Errorf("union member %s must be optional", member.name), member.field.RawField))
+			return
+		}
+	}
+
+	for i, member := range members {
+		branch := apiext.JSONSchemaProps{Required: []string{member.name}}
+		if discriminator != Empty {
+			branch.Properties = map[string]apiext.JSONSchemaProps{discriminator: {}}
+			ctx.at("oneOf", strconv.Itoa(len(props.OneOf)), "properties", discriminator).setKeyword(constKeyword, member.value)
+		}
+		var others []apiext.JSONSchemaProps
+		for j, other := range members {
+			if j != i {
+				others = append(others, apiext.JSONSchemaProps{Required: []string{other.name}})
+			}
+		}
+		if len(others) > 0 {
+			branch.Not = &apiext.JSONSchemaProps{AnyOf: others}
+		}
+		props.OneOf = append(props.OneOf, branch)
+	}
+}
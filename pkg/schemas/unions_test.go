@@ -0,0 +1,65 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+func TestUnion(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/plugins")
+	doc := documents["plugins.json"]
+	definitions := doc[definitionsKeyword].(map[string]interface{})
+	source := definitions["Source"].(map[string]interface{})
+	branches, ok := source["oneOf"].([]interface{})
+	if !ok || len(branches) != 2 {
+		t.Fatalf("unexpected branches %v", source["oneOf"])
+	}
+	first := branches[0].(map[string]interface{})
+	discriminator := first["properties"].(map[string]interface{})["type"].(map[string]interface{})
+	if discriminator[constKeyword] != "S3" {
+		t.Errorf("unexpected discriminator %v", discriminator)
+	}
+
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Source",
+		definitionsKeyword: definitions,
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"type": "S3", "s3": map[string]interface{}{"bucket": "b"}}, true},
+		{map[string]interface{}{"kafka": map[string]interface{}{"topic": "t"}}, true},
+		{map[string]interface{}{"type": "Kafka", "s3": map[string]interface{}{"bucket": "b"}}, false},
+		{map[string]interface{}{"type": "S3", "s3": map[string]interface{}{"bucket": "b"},
+			"kafka": map[string]interface{}{"topic": "t"}}, false},
+		{map[string]interface{}{"type": "S3"}, false},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v", test.instance, test.valid)
+		}
+	}
+}
+
+func TestUnionExamples(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/plugins")
+	doc := document{
+		"$ref":             "#/definitions/Source",
+		definitionsKeyword: documents["plugins.json"][definitionsKeyword],
+	}
+	for _, maximal := range []bool{false, true} {
+		e := &exampleGenerator{docName: "plugins.json", doc: doc, maximal: maximal, expanding: make(map[string]int)}
+		example, err := e.value(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := validateExample(doc, example); err != nil {
+			t.Errorf("maximal %v: %v", maximal, err)
+		}
+	}
+}
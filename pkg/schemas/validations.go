@@ -0,0 +1,304 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+const (
+	ifKeyword                = "if"
+	thenKeyword              = "then"
+	elseKeyword              = "else"
+	notKeyword               = "not"
+	containsKeyword          = "contains"
+	minContainsKeyword       = "minContains"
+	maxContainsKeyword       = "maxContains"
+	dependentRequiredKeyword = "dependentRequired"
+	dependentSchemasKeyword  = "dependentSchemas"
+	// dependenciesKeyword is the draft-07 keyword that was split into
+	// dependentRequired and dependentSchemas in 2019-09
+	dependenciesKeyword = "dependencies"
+)
+
+// If is a schema, in JSON, that decides whether values must be valid against Then or Else
+type If string
+
+func (m If) ApplyToKeywords(keywords map[string]interface{}) error {
+	return setSchemaKeyword(keywords, ifKeyword, string(m))
+}
+
+// Then is a schema, in JSON, that values valid against If must be valid against
+type Then string
+
+func (m Then) ApplyToKeywords(keywords map[string]interface{}) error {
+	return setSchemaKeyword(keywords, thenKeyword, string(m))
+}
+
+// Else is a schema, in JSON, that values not valid against If must be valid against
+type Else string
+
+func (m Else) ApplyToKeywords(keywords map[string]interface{}) error {
+	return setSchemaKeyword(keywords, elseKeyword, string(m))
+}
+
+// Not is a schema, in JSON, that values must not be valid against. It is set on the Not field
+// of the schema, and its keywords that apiext.JSONSchemaProps has no field for are kept aside.
+type Not string
+
+func (m Not) ApplyToSchema(schema *apiext.JSONSchemaProps) error {
+	if schema.Not != nil {
+		return fmt.Errorf("the schema already has a %s schema", notKeyword)
+	}
+	not, _, err := splitSchema(notKeyword, string(m))
+	if err != nil {
+		return err
+	}
+	schema.Not = not
+	return nil
+}
+
+func (m Not) SubschemaKeywords() (string, keywordTable) {
+	// an invalid schema is reported by ApplyToSchema
+	_, keywords, _ := splitSchema(notKeyword, string(m))
+	return notKeyword, keywords
+}
+
+// Contains is a schema, in JSON, that some items of an array must be valid against
+type Contains string
+
+func (m Contains) ApplyToKeywords(keywords map[string]interface{}) error {
+	return setSchemaKeyword(keywords, containsKeyword, string(m))
+}
+
+// MinContains is the minimum number of items of an array that are valid against Contains
+type MinContains int
+
+func (m MinContains) ApplyToKeywords(keywords map[string]interface{}) error {
+	keywords[minContainsKeyword] = int(m)
+	return nil
+}
+
+// MaxContains is the maximum number of items of an array that are valid against Contains
+type MaxContains int
+
+func (m MaxContains) ApplyToKeywords(keywords map[string]interface{}) error {
+	keywords[maxContainsKeyword] = int(m)
+	return nil
+}
+
+// PropertyNames is a schema, in JSON, that the names of the properties of an object must be valid against
+type PropertyNames string
+
+func (m PropertyNames) ApplyToKeywords(keywords map[string]interface{}) error {
+	return setSchemaKeyword(keywords, propertyNamesKeyword, string(m))
+}
+
+// Const is the only value accepted
+type Const struct {
+	Value interface{}
+}
+
+func (m Const) ApplyToKeywords(keywords map[string]interface{}) error {
+	keywords[constKeyword] = m.Value
+	return nil
+}
+
+// DependentRequired lists the properties that are required when Property is set
+type DependentRequired struct {
+	Property string
+	Required []string
+}
+
+func (m DependentRequired) ApplyToKeywords(keywords map[string]interface{}) error {
+	dependents, ok := keywords[dependentRequiredKeyword].(map[string][]string)
+	if !ok {
+		dependents = make(map[string][]string)
+		keywords[dependentRequiredKeyword] = dependents
+	}
+	dependents[m.Property] = append(dependents[m.Property], m.Required...)
+	return nil
+}
+
+// DependentSchema is a schema, in JSON, that objects must be valid against when Property is set
+type DependentSchema struct {
+	Property string
+	Schema   string
+}
+
+func (m DependentSchema) ApplyToKeywords(keywords map[string]interface{}) error {
+	dependentSchema, err := parseSchema(dependentSchemasKeyword, m.Schema)
+	if err != nil {
+		return err
+	}
+	dependents, ok := keywords[dependentSchemasKeyword].(map[string]interface{})
+	if !ok {
+		dependents = make(map[string]interface{})
+		keywords[dependentSchemasKeyword] = dependents
+	}
+	dependents[m.Property] = dependentSchema
+	return nil
+}
+
+// validationMarkerHelp describes the markers of keywords that apiext.JSONSchemaProps has no field for
+var validationMarkerHelp = map[string]string{
+	"fybrik:validation:if":                "schema, in JSON, that decides whether values must match the then or the else schema",
+	"fybrik:validation:then":              "schema, in JSON, that values matching the if schema must match",
+	"fybrik:validation:else":              "schema, in JSON, that values not matching the if schema must match",
+	"fybrik:validation:not":               "schema, in JSON, that values must not match",
+	"fybrik:validation:contains":          "schema, in JSON, that some items of the array must match",
+	"fybrik:validation:minContains":       "minimum number of items of the array that match the contains schema",
+	"fybrik:validation:maxContains":       "maximum number of items of the array that match the contains schema",
+	"fybrik:validation:propertyNames":     "schema, in JSON, that the names of the properties of the object must match",
+	"fybrik:validation:const":             "the only value accepted",
+	"fybrik:validation:dependentRequired": "properties that are required when the given property is set",
+	"fybrik:validation:dependentSchemas":  "schema, in JSON, that the object must match when the given property is set",
+}
+
+// validationMarkers are the definitions, for types and fields, of the markers of keywords
+// that apiext.JSONSchemaProps has no field for
+var validationMarkers = func() []*markers.Definition {
+	outputs := map[string]interface{}{
+		"fybrik:validation:if":                If(Empty),
+		"fybrik:validation:then":              Then(Empty),
+		"fybrik:validation:else":              Else(Empty),
+		"fybrik:validation:not":               Not(Empty),
+		"fybrik:validation:contains":          Contains(Empty),
+		"fybrik:validation:minContains":       MinContains(0),
+		"fybrik:validation:maxContains":       MaxContains(0),
+		"fybrik:validation:propertyNames":     PropertyNames(Empty),
+		"fybrik:validation:dependentRequired": DependentRequired{},
+		"fybrik:validation:dependentSchemas":  DependentSchema{},
+	}
+	var definitions []*markers.Definition
+	for _, target := range []markers.TargetType{markers.DescribesType, markers.DescribesField} {
+		for _, name := range sortedKeys(outputs) {
+			definitions = append(definitions, markers.Must(markers.MakeDefinition(name, target, outputs[name])))
+		}
+		definitions = append(definitions, markers.Must(markers.MakeAnyTypeDefinition("fybrik:validation:const", target, Const{})))
+	}
+	return definitions
+}()
+
+// parseSchema parses a schema given in JSON in the argument of a marker
+func parseSchema(keyword, raw string) (interface{}, error) {
+	var schema interface{}
+	if err := unmarshalJSON([]byte(raw), &schema); err != nil {
+		return nil, fmt.Errorf("invalid %s schema %s: %w", keyword, raw, err)
+	}
+	switch schema.(type) {
+	case map[string]interface{}, bool:
+		return schema, nil
+	}
+	return nil, fmt.Errorf("invalid %s schema %s: expected an object or a boolean", keyword, raw)
+}
+
+// splitSchema parses a schema given in JSON in the argument of a marker, into the schema
+// apiext.JSONSchemaProps holds and the keywords it has no field for
+func splitSchema(keyword, raw string) (*apiext.JSONSchemaProps, keywordTable, error) {
+	generic, err := parseSchema(keyword, raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, isObject := generic.(map[string]interface{}); !isObject {
+		return nil, nil, fmt.Errorf("invalid %s schema %s: expected an object", keyword, raw)
+	}
+	schema := &apiext.JSONSchemaProps{}
+	if err := json.Unmarshal([]byte(raw), schema); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s schema %s: %w", keyword, raw, err)
+	}
+	represented, err := toDocument(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	keywords := make(keywordTable)
+	collectMissingKeywords(generic, map[string]interface{}(represented), Empty, keywords)
+	return schema, keywords, nil
+}
+
+// collectMissingKeywords adds to the table the keywords of a generic JSON schema that
+// its represented form lacks, by the JSON pointer of their subschema
+func collectMissingKeywords(generic, represented interface{}, pointer string, keywords keywordTable) {
+	switch typed := generic.(type) {
+	case map[string]interface{}:
+		representedMap, _ := represented.(map[string]interface{})
+		for key, value := range typed {
+			if representedValue, found := representedMap[key]; found {
+				collectMissingKeywords(value, representedValue, pointer+pointerTo(key), keywords)
+			} else {
+				keywords.keywordsAt(pointer)[key] = value
+			}
+		}
+	case []interface{}:
+		representedList, _ := represented.([]interface{})
+		for i, item := range typed {
+			if i < len(representedList) {
+				collectMissingKeywords(item, representedList[i], pointer+pointerTo(strconv.Itoa(i)), keywords)
+			}
+		}
+	}
+}
+
+// setSchemaKeyword sets a keyword whose value is a schema given in JSON
+func setSchemaKeyword(keywords map[string]interface{}, keyword, raw string) error {
+	schema, err := parseSchema(keyword, raw)
+	if err != nil {
+		return err
+	}
+	keywords[keyword] = schema
+	return nil
+}
+
+// draft07UnsupportedKeywords are the keywords of markers that draft-07 has no equivalent for
+var draft07UnsupportedKeywords = []string{minContainsKeyword, maxContainsKeyword}
+
+// toDraft07 rewrites the keywords of a document that draft-07 lacks: dependentRequired and
+// dependentSchemas become dependencies, and minContains and maxContains, which draft-07 can
+// not express, are dropped with a warning
+func toDraft07(docName string, doc map[string]interface{}) {
+	for _, keyword := range dropKeywords(doc, draft07UnsupportedKeywords) {
+		log.Printf("warning: draft-07 has no %s keyword, it is dropped from %s", keyword, docName)
+	}
+	walkSchemas(doc, toDependencies)
+}
+
+// toDependencies replaces dependentRequired and dependentSchemas, that draft-07 lacks, with
+// dependencies. A property with both gets the schema requiring the properties in an allOf.
+func toDependencies(schema map[string]interface{}) {
+	dependencies, _ := schema[dependenciesKeyword].(map[string]interface{})
+	for _, keyword := range []string{dependentRequiredKeyword, dependentSchemasKeyword} {
+		dependents, ok := schema[keyword].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if dependencies == nil {
+			dependencies = make(map[string]interface{})
+		}
+		for property, dependent := range dependents {
+			if other, exists := dependencies[property]; exists {
+				dependent = map[string]interface{}{"allOf": []interface{}{dependencySchema(other), dependencySchema(dependent)}}
+			}
+			dependencies[property] = dependent
+		}
+		delete(schema, keyword)
+	}
+	if dependencies != nil {
+		schema[dependenciesKeyword] = dependencies
+	}
+}
+
+// dependencySchema returns the schema form of a dependency, which is either a schema
+// or the list of the properties it requires
+func dependencySchema(dependency interface{}) interface{} {
+	if required, ok := dependency.([]interface{}); ok {
+		return map[string]interface{}{"required": required}
+	}
+	return dependency
+}
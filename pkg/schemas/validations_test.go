@@ -0,0 +1,151 @@
+package schemas
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/xeipuuv/gojsonschema"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestValidationMarkers(t *testing.T) {
+	documents := generate(t, Generator{}, "../../testPkgs/validations")
+	definitions := documents["validations.json"][definitionsKeyword].(map[string]interface{})
+	connection := definitions["Connection"].(map[string]interface{})
+	for _, keyword := range []string{ifKeyword, thenKeyword, elseKeyword, dependenciesKeyword} {
+		if _, ok := connection[keyword]; !ok {
+			t.Errorf("expected %s in %v", keyword, connection)
+		}
+	}
+	if _, ok := connection[dependentRequiredKeyword]; ok {
+		t.Errorf("expected dependentRequired as dependencies in draft-07 %v", connection)
+	}
+	roles := connection["properties"].(map[string]interface{})["roles"].(map[string]interface{})
+	if _, ok := roles[minContainsKeyword]; ok || roles[containsKeyword] == nil {
+		t.Errorf("expected contains without minContains in draft-07 %v", roles)
+	}
+
+	schema := gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref":             "#/definitions/Connection",
+		definitionsKeyword: definitions,
+	})
+	for _, test := range []struct {
+		instance map[string]interface{}
+		valid    bool
+	}{
+		{map[string]interface{}{"protocol": "kafka", "topic": "a", "version": 1}, true},
+		{map[string]interface{}{"protocol": "kafka", "version": 1}, false},
+		{map[string]interface{}{"protocol": "http", "version": 1}, true},
+		{map[string]interface{}{"protocol": "http", "topic": "a", "version": 1}, false},
+		{map[string]interface{}{"protocol": "http", "version": 2}, false},
+		{map[string]interface{}{"protocol": "http", "version": 1, "username": "a"}, false},
+		{map[string]interface{}{"protocol": "http", "version": 1, "username": "a", "password": "b"}, true},
+		{map[string]interface{}{"protocol": "http", "version": 1, "tls": true}, false},
+		{map[string]interface{}{"protocol": "http", "version": 1, "tls": true, "port": 443}, true},
+		{map[string]interface{}{"protocol": "http", "version": 1, "roles": []interface{}{"user"}}, false},
+		{map[string]interface{}{"protocol": "http", "version": 1, "roles": []interface{}{"user", "admin"}}, true},
+		{map[string]interface{}{"protocol": "http", "version": 1, "headers": map[string]interface{}{"A": "b"}}, false},
+		{map[string]interface{}{"protocol": "http", "version": 1, "priority": 0}, false},
+	} {
+		result, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(test.instance))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid() != test.valid {
+			t.Errorf("%v: expected valid %v", test.instance, test.valid)
+		}
+	}
+}
+
+func TestValidationMarkersDialect(t *testing.T) {
+	documents := generate(t, Generator{Dialect: Dialect201909}, "../../testPkgs/validations")
+	definitions := documents["validations.json"][defsKeyword].(map[string]interface{})
+	connection := definitions["Connection"].(map[string]interface{})
+	if !reflect.DeepEqual(connection[dependentRequiredKeyword], map[string]interface{}{"username": []interface{}{"password"}}) {
+		t.Errorf("unexpected dependentRequired %v", connection[dependentRequiredKeyword])
+	}
+	roles := connection["properties"].(map[string]interface{})["roles"].(map[string]interface{})
+	if roles[minContainsKeyword] == nil || roles[containsKeyword] == nil {
+		t.Errorf("unexpected roles %v", roles)
+	}
+}
+
+func TestValidationMarkersOpenAPI30(t *testing.T) {
+	documents := generate(t, Generator{OpenAPI: OpenAPI30}, "../../testPkgs/validations")
+	components := documents[openAPIDocumentName]["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	connection := components["Connection"].(map[string]interface{})
+	priority := connection["properties"].(map[string]interface{})["priority"].(map[string]interface{})
+	if priority[notKeyword] == nil {
+		t.Errorf("expected not to be kept in %v", priority)
+	}
+	for _, keyword := range unsupportedOpenAPI30Keywords {
+		walkSchemas(connection, func(schema map[string]interface{}) {
+			if _, ok := schema[keyword]; ok {
+				t.Errorf("unexpected %s in OpenAPI 3.0 schema %v", keyword, schema)
+			}
+		})
+	}
+}
+
+func TestExampleConditionals(t *testing.T) {
+	var doc document
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["protocol", "user"],
+		"properties": {
+			"protocol": {"type": "string", "enum": ["http", "kafka"]},
+			"topic": {"type": "string"},
+			"user": {"type": "string"},
+			"password": {"type": "string"},
+			"priority": {"type": "integer", "not": {"const": 0}},
+			"roles": {"type": "array", "items": {"type": "string"}, "contains": {"const": "admin"}, "minContains": 2}
+		},
+		"if": {"properties": {"protocol": {"const": "kafka"}}},
+		"then": {"required": ["topic"]},
+		"else": {"required": ["priority", "roles"]},
+		"dependentRequired": {"user": ["password"]}
+	}`), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &exampleGenerator{docName: "doc.json", doc: doc, expanding: make(map[string]int)}
+	value, err := e.value(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := validateExample(doc, value); err != nil {
+		t.Error(err)
+	}
+	example := value.(map[string]interface{})
+	for _, name := range []string{"password", "priority", "roles"} {
+		if _, ok := example[name]; !ok {
+			t.Errorf("expected %s in %v", name, example)
+		}
+	}
+	if roles := example["roles"].([]interface{}); len(roles) != 2 || roles[1] != "admin" {
+		t.Errorf("unexpected roles %v", roles)
+	}
+}
+
+func TestNotMarker(t *testing.T) {
+	marker := Not(`{"minimum":1,"properties":{"name":{"const":"a"}},"const":0}`)
+	props := &apiext.JSONSchemaProps{}
+	if err := marker.ApplyToSchema(props); err != nil {
+		t.Fatal(err)
+	}
+	if props.Not == nil || props.Not.Minimum == nil || *props.Not.Minimum != 1 || props.Not.Properties["name"].Type != Empty {
+		t.Errorf("unexpected not schema %v", props.Not)
+	}
+	keyword, keywords := marker.SubschemaKeywords()
+	if keyword != notKeyword || !reflect.DeepEqual(keywords, keywordTable{
+		Empty:              {constKeyword: json.Number("0")},
+		"/properties/name": {constKeyword: "a"},
+	}) {
+		t.Errorf("unexpected keywords %s %v", keyword, keywords)
+	}
+	if err := marker.ApplyToSchema(props); err == nil {
+		t.Error("expected an error for a schema that already has a not schema")
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package ambiguous
+
+// Action is an action performed on a dataset
+// +fybrik:validation:implementations=RedactAction;RemoveAction
+type Action interface {
+	ActionName() string
+}
+
+// RedactAction redacts columns
+type RedactAction struct {
+	Columns []string `json:"columns"`
+}
+
+func (RedactAction) ActionName() string { return "redact" }
+
+// RemoveAction removes columns, and has the same shape as RedactAction
+type RemoveAction struct {
+	// Columns are the removed columns
+	Columns []string `json:"columns"`
+
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+func (RemoveAction) ActionName() string { return "remove" }
+
+// Policy applies an action to a dataset
+type Policy struct {
+	Action Action `json:"action"`
+}
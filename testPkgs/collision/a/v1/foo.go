@@ -0,0 +1,9 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1
+
+// Foo is a type with the same name in another package of the same name
+type Foo struct {
+	Name string `json:"name"`
+}
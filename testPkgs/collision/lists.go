@@ -0,0 +1,20 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package collision
+
+import (
+	av1 "fybrik.io/json-schema-generator/testPkgs/collision/a/v1"
+	bv1 "fybrik.io/json-schema-generator/testPkgs/collision/b/v1"
+)
+
+// List is a list of items
+type List[T any] struct {
+	Items []T `json:"items"`
+}
+
+// Lists has instantiations whose type arguments have the same name in different packages
+type Lists struct {
+	A List[av1.Foo] `json:"a"`
+	B List[bv1.Foo] `json:"b"`
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+package embedded
+
+// Metadata identifies a resource
+type Metadata struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Status is the observed state of a resource
+type Status struct {
+	Phase string `json:"phase"`
+}
+
+// Resource embeds its metadata
+type Resource struct {
+	Metadata `json:",inline"`
+	Spec     string `json:"spec"`
+}
+
+// FlatResource merges its metadata and status into its properties
+type FlatResource struct {
+	// +fybrik:validation:flatten
+	Metadata `json:",inline"`
+	// +fybrik:validation:flatten
+	*Status `json:",inline"`
+	Spec    string `json:"spec"`
+}
+
+// Named shadows the name of its metadata
+type Named struct {
+	Metadata `json:",inline"`
+	Name     string `json:"name"`
+}
+
+// Deployment has anonymous structs at any depth
+type Deployment struct {
+	// Spec is the desired state
+	Spec struct {
+		// Replicas is the number of instances
+		// +kubebuilder:validation:Minimum=1
+		Replicas int `json:"replicas"`
+		// Ports exposed by the instances
+		Ports []struct {
+			Port int    `json:"port"`
+			Name string `json:"name,omitempty"`
+		} `json:"ports,omitempty"`
+	} `json:"spec"`
+	// Volumes by name
+	Volumes map[string]struct {
+		Path string `json:"path"`
+	} `json:"volumes,omitempty"`
+}
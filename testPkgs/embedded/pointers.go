@@ -0,0 +1,38 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package embedded
+
+// Limits has pointer fields
+type Limits struct {
+	CPU *int `json:"cpu,omitempty"`
+	// +fybrik:validation:nullable=false
+	Memory *int `json:"memory,omitempty"`
+	// +fybrik:validation:nullable
+	Name   string  `json:"name"`
+	Status *Status `json:"status,omitempty"`
+	Region *Region `json:"region,omitempty"`
+}
+
+// Region is a cloud region
+type Region string
+
+const (
+	EastRegion Region = "east"
+	WestRegion Region = "west"
+)
+
+// Scaling sets the number of instances
+type Scaling struct {
+	// Replicas of the deployment
+	//
+	// Deprecated: use Scale instead.
+	Replicas int `json:"replicas,omitempty"`
+	// Scale of the deployment
+	Scale int `json:"scale"`
+}
+
+// Instances is a number of instances
+//
+// Deprecated: use Scaling.
+type Instances int
@@ -0,0 +1,32 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+package encoded
+
+// Level is a level of detail
+// +kubebuilder:validation:Maximum=3
+type Level uint8
+
+// Encoded has fields encoded as JSON strings
+type Encoded struct {
+	// Count of items
+	// +kubebuilder:validation:Minimum=1
+	Count   int      `json:"count,string"`
+	Ratio   *float64 `json:"ratio,omitempty,string"`
+	Enabled bool     `json:"enabled,string"`
+	Name    string   `json:"name,string"`
+	Level   Level    `json:"level,string"`
+	// +kubebuilder:validation:Enum=1;2
+	Mode   int    `json:"mode,string"`
+	Detail Detail `json:"detail,string"`
+	// +kubebuilder:default=5
+	Limit int `json:"limit,omitempty,string"`
+	// +fybrik:validation:const=1
+	Version int `json:"version,string"`
+}
+
+// Detail is not encoded as a string, as it is a struct
+type Detail struct {
+	Size int `json:"size"`
+}
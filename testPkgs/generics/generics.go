@@ -0,0 +1,44 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+package generics
+
+import "fybrik.io/json-schema-generator/testPkgs/schemapkg"
+
+// List is a page of items
+type List[T any] struct {
+	// Items of the page
+	Items []T `json:"items"`
+	// Next is the token of the next page
+	Next string `json:"next,omitempty"`
+}
+
+// Pair is a key and a value
+type Pair[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// Index maps names to entries
+type Index[V any] struct {
+	Entries map[string]V          `json:"entries"`
+	Pages   List[Pair[string, V]] `json:"pages"`
+}
+
+// Asset is a data asset
+type Asset struct {
+	Name string `json:"name"`
+}
+
+// Catalog uses instantiations of the generic types
+type Catalog struct {
+	Assets   List[Asset]                 `json:"assets"`
+	Pointers List[*Asset]                `json:"pointers,omitempty"`
+	Owners   Pair[string, *Asset]        `json:"owners,omitempty"`
+	Sources  Pair[string, Asset]         `json:"sources,omitempty"`
+	Counts   Pair[string, int]           `json:"counts"`
+	Index    Index[Asset]                `json:"index"`
+	Tags     map[string]List[string]     `json:"tags,omitempty"`
+	External List[schemapkg.SchemaType1] `json:"external,omitempty"`
+}
@@ -0,0 +1,39 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package godoc has doc comments in the Go doc syntax.
+//
+// Its descriptions are in CommonMark.
+//
+// +fybrik:validation:schema
+package godoc
+
+import "fybrik.io/json-schema-generator/testPkgs/embedded"
+
+// Catalog lists [Asset] items. It is kept up to date by the server.
+//
+// # Usage
+//
+// Assets are listed by name:
+//
+//	catalog.Assets["name"]
+//
+// A catalog has:
+//   - assets, see [Asset]
+//   - a status, see [embedded.Status]
+//   - an owner, see [Catalog.Owner]
+type Catalog struct {
+	// Assets by name, each an [Asset]
+	Assets map[string]Asset `json:"assets"`
+	// Status of the catalog
+	//
+	// Deprecated: see [embedded.Status] instead.
+	Status embedded.Status `json:"status,omitempty"`
+	// Owner of the catalog, not an [io.Reader]
+	Owner string `json:"owner"`
+}
+
+// Asset is a data_set of the catalog, see [Catalog.Assets]
+type Asset struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,32 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package helmvalues
+
+import schemapkg "fybrik.io/json-schema-generator/testPkgs/schemapkg"
+
+// Values are the values of the sample chart
+// +fybrik:validation:helm="charts/sample"
+type Values struct {
+	// Number of replicas of the deployment
+	// +kubebuilder:validation:Minimum=1
+	ReplicaCount int `json:"replicaCount"`
+
+	// Image of the deployment
+	Image Image `json:"image"`
+
+	// Taxonomy settings
+	// +optional
+	Taxonomy schemapkg.SchemaType1 `json:"taxonomy,omitempty"`
+}
+
+// Image is a container image
+type Image struct {
+	// Repository of the image
+	Repository string `json:"repository"`
+
+	// Policy for pulling the image
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	PullPolicy string `json:"pullPolicy,omitempty"`
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+package mapkeys
+
+import "fmt"
+
+// Coordinates are written as x,y
+// +kubebuilder:validation:Pattern=`^-?[0-9]+,-?[0-9]+$`
+type Coordinates struct {
+	X int
+	Y int
+}
+
+func (c Coordinates) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", c.X, c.Y)), nil
+}
+
+// Grid has maps with keys that are not strings
+type Grid struct {
+	Rows    map[int]string         `json:"rows"`
+	Columns map[uint8]string       `json:"columns"`
+	Cells   map[Coordinates]string `json:"cells"`
+}
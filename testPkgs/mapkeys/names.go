@@ -0,0 +1,26 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package mapkeys
+
+// Region is a cloud region
+type Region string
+
+const (
+	EastRegion Region = "east"
+	WestRegion Region = "west"
+)
+
+// Zone is a zone of a region
+// +kubebuilder:validation:Pattern=`^[a-z]+-[0-9]$`
+type Zone string
+
+// Label is any label
+type Label string
+
+// Quotas are keyed by named string types
+type Quotas struct {
+	Regions map[Region]int   `json:"regions"`
+	Zones   map[Zone]int     `json:"zones"`
+	Labels  map[Label]string `json:"labels"`
+}
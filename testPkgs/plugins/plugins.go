@@ -0,0 +1,106 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package plugins
+
+// Action is an action performed on a dataset
+// +fybrik:validation:implementations=RedactAction;RemoveAction
+type Action interface {
+	ActionName() string
+}
+
+// Transform changes the values of a dataset
+// +fybrik:validation:discoverImplementations
+type Transform interface {
+	Transform()
+}
+
+// RedactAction redacts columns
+type RedactAction struct {
+	Columns []string `json:"redact"`
+}
+
+func (RedactAction) ActionName() string { return "redact" }
+
+func (RedactAction) Transform() {}
+
+// RemoveAction removes columns
+type RemoveAction struct {
+	Columns []string `json:"remove"`
+}
+
+func (*RemoveAction) ActionName() string { return "remove" }
+
+// HashTransform hashes columns
+type HashTransform struct {
+	Algorithm Algorithm `json:"algorithm"`
+	Level     Level     `json:"level,omitempty"`
+}
+
+func (*HashTransform) Transform() {}
+
+// Policy applies actions and transforms to a dataset
+type Policy struct {
+	Action Action `json:"action"`
+
+	Transforms []Transform `json:"transforms,omitempty"`
+
+	// Fallback is the action taken when the policy can not be evaluated
+	// +fybrik:validation:implementations=RemoveAction
+	Fallback interface{} `json:"fallback,omitempty"`
+
+	Mode Mode `json:"mode,omitempty"`
+}
+
+// Algorithm is a hash algorithm
+type Algorithm string
+
+const (
+	// SHA256 is the SHA-2 algorithm with 256 bits digests
+	SHA256 Algorithm = "sha256"
+	MD5    Algorithm = "md5" // deprecated
+	// SHA2 is an alias of SHA256
+	SHA2 Algorithm = SHA256
+)
+
+// Level is a level of protection
+type Level int
+
+const (
+	Low Level = iota + 1
+	High
+)
+
+// Mode is a mode of evaluation
+// +kubebuilder:validation:Enum=strict;lenient;audit
+type Mode string
+
+const (
+	Strict  Mode = "strict"
+	Lenient Mode = "lenient"
+)
+
+// Source is where a dataset is read from
+// +union
+type Source struct {
+	// Type is the kind of source that is set
+	// +unionDiscriminator
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// +optional
+	S3 *S3Source `json:"s3,omitempty"`
+
+	// +optional
+	Kafka *KafkaSource `json:"kafka,omitempty"`
+}
+
+// S3Source is an object storage bucket
+type S3Source struct {
+	Bucket string `json:"bucket"`
+}
+
+// KafkaSource is a topic
+type KafkaSource struct {
+	Topic string `json:"topic"`
+}
@@ -0,0 +1,13 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package profiles
+
+import "fybrik.io/json-schema-generator/testPkgs/profiles/taxonomy"
+
+// +fybrik:validation:object="account"
+type Account struct {
+	Spec taxonomy.Spec `json:"spec"`
+	// +fybrik:validation:readOnly
+	Status taxonomy.Status `json:"status"`
+}
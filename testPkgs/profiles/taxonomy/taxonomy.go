@@ -0,0 +1,22 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+package taxonomy
+
+// Spec is the desired state of an account
+type Spec struct {
+	// ID is generated by the server
+	// +fybrik:validation:readOnly
+	ID string `json:"id"`
+	// Owner of the account
+	Owner string `json:"owner"`
+	// Password of the owner
+	// +fybrik:validation:writeOnly
+	Password string `json:"password"`
+}
+
+// Status is the observed state of an account
+type Status struct {
+	Phase string `json:"phase"`
+}
@@ -0,0 +1,10 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package unsatisfiable
+
+// Limits has a bound that no value of its kind is within
+type Limits struct {
+	// +kubebuilder:validation:Maximum=-5
+	Count uint `json:"count"`
+}
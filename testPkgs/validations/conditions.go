@@ -0,0 +1,30 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// +fybrik:validation:schema
+package validations
+
+// Connection is a connection to a messaging system
+// +fybrik:validation:if=`{"properties":{"protocol":{"const":"kafka"}}}`
+// +fybrik:validation:then=`{"required":["topic"]}`
+// +fybrik:validation:else=`{"not":{"required":["topic"]}}`
+// +fybrik:validation:dependentRequired:property=username,required=password
+// +fybrik:validation:dependentSchemas:property=tls,schema=`{"required":["port"]}`
+type Connection struct {
+	// +kubebuilder:validation:Enum=kafka;http
+	Protocol string `json:"protocol"`
+	Topic    string `json:"topic,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	TLS      bool   `json:"tls,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	// +fybrik:validation:const=1
+	Version int `json:"version"`
+	// +fybrik:validation:contains=`{"const":"admin"}`
+	// +fybrik:validation:minContains=1
+	Roles []string `json:"roles,omitempty"`
+	// +fybrik:validation:propertyNames=`{"pattern":"^[a-z]+$"}`
+	Headers map[string]string `json:"headers,omitempty"`
+	// +fybrik:validation:not=`{"const":0}`
+	Priority int `json:"priority,omitempty"`
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package validations
+
+// Port is a TCP port
+// +kubebuilder:validation:Minimum=1
+// +kubebuilder:validation:Maximum=70000
+type Port uint16
+
+// Sizes has integers of every kind
+type Sizes struct {
+	Small  int8   `json:"small"`
+	Medium int32  `json:"medium"`
+	Count  uint   `json:"count"`
+	Octet  byte   `json:"octet"`
+	Char   rune   `json:"char"`
+	Large  uint64 `json:"large"`
+	// +kubebuilder:validation:Minimum=-1000
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:validation:ExclusiveMaximum=true
+	Offset int16 `json:"offset"`
+	Port   Port  `json:"port"`
+	Ratio  int   `json:"ratio"`
+	// +kubebuilder:validation:Minimum=0
+	Total int64 `json:"total"`
+}